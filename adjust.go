@@ -0,0 +1,163 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrOff is returned by methods which require the light bulb to be on.
+var ErrOff = errors.New("bulb is off")
+
+// relativeDuration is the duration of the smooth effect used by Dim and
+// Brighten.
+const relativeDuration = 300 * time.Millisecond
+
+// AdjustAction describes how a setting is changed relative to its current
+// value.
+type AdjustAction string
+
+var (
+	AdjustIncrease AdjustAction = "increase"
+	AdjustDecrease AdjustAction = "decrease"
+	// AdjustCircle increases the value and wraps around at the maximum.
+	AdjustCircle AdjustAction = "circle"
+)
+
+// AdjustBrightness will change the light bulbs brightness without knowing
+// its current value.
+func (b *Bulb) AdjustBrightness(action AdjustAction) error {
+	_, err := b.Send(MethodSetAdjust, action, "bright")
+	return err
+}
+
+// AdjustColorTemp will change the light bulbs color temperature without
+// knowing its current value.
+func (b *Bulb) AdjustColorTemp(action AdjustAction) error {
+	_, err := b.Send(MethodSetAdjust, action, "ct")
+	return err
+}
+
+// AdjustColor will cycle the light bulbs color. The light bulb only supports
+// AdjustCircle for colors.
+func (b *Bulb) AdjustColor() error {
+	_, err := b.Send(MethodSetAdjust, AdjustCircle, "color")
+	return err
+}
+
+// NudgeBrightness will change the light bulbs brightness by the given
+// percentage, between -100 and 100, over the duration.
+func (b *Bulb) NudgeBrightness(percent int, d time.Duration) error {
+	return b.nudge(MethodAdjustBright, percent, d)
+}
+
+// NudgeColorTemp will change the light bulbs color temperature by the given
+// percentage, between -100 and 100, over the duration.
+func (b *Bulb) NudgeColorTemp(percent int, d time.Duration) error {
+	return b.nudge(MethodAdjustCT, percent, d)
+}
+
+// NudgeColor will change the light bulbs color by the given percentage,
+// between -100 and 100, over the duration.
+func (b *Bulb) NudgeColor(percent int, d time.Duration) error {
+	return b.nudge(MethodAdjustColor, percent, d)
+}
+
+func (b *Bulb) nudge(method Method, percent int, d time.Duration) error {
+	if d < minEffectDuration {
+		d = minEffectDuration
+	}
+	_, err := b.Send(method, clamp(percent, -100, 100), d.Milliseconds())
+	if errors.Is(err, ErrUnsupported) {
+		return fmt.Errorf("%s requires a newer firmware: %w", method, err)
+	}
+	return err
+}
+
+// Dim will lower the light bulbs brightness by step, fading smoothly to the
+// new value. It returns ErrOff if the light bulb is off.
+func (b *Bulb) Dim(step int) error {
+	return b.brightenBy(-step)
+}
+
+// Brighten will raise the light bulbs brightness by step, fading smoothly to
+// the new value. It returns ErrOff if the light bulb is off.
+func (b *Bulb) Brighten(step int) error {
+	return b.brightenBy(step)
+}
+
+func (b *Bulb) brightenBy(step int) error {
+	props, err := b.GetProp("power", "bright")
+	if err != nil {
+		return err
+	}
+	if props["power"] != "on" {
+		return ErrOff
+	}
+	current, err := strconv.Atoi(props["bright"])
+	if err != nil {
+		return fmt.Errorf("invalid bright property: %+v", err)
+	}
+	// The bulb reports the brightness within the bounds, the step applies to
+	// the brightness passed to Brightness.
+	current = b.unmapBrightness(current)
+	return b.BrightnessWithEffect(clamp(current+step, 1, 100), Smooth, relativeDuration)
+}
+
+// FadeBrightness will fade the light bulbs brightness to target over the
+// given duration. Unlike a smooth effect, the fade may take much longer, e.g.
+// ten minutes, since it runs as a color flow of many small steps. The color
+// is kept as it is.
+func (b *Bulb) FadeBrightness(target int, d time.Duration) error {
+	flow, err := b.fadeFlow(target, d)
+	if err != nil {
+		return err
+	}
+	return b.StartColorFlow(len(flow), FlowActionStay, flow)
+}
+
+// fadeFlow builds the color flow fading the light bulbs brightness from its
+// current brightness to target.
+func (b *Bulb) fadeFlow(target int, d time.Duration) ([]FlowTuple, error) {
+	state, err := b.State()
+	if err != nil {
+		return nil, err
+	}
+	target = b.remapBrightness(clamp(target, 1, 100))
+
+	mode, value := FlowModeColor, int(state.RGB.R)<<16|int(state.RGB.G)<<8|int(state.RGB.B)
+	switch state.ColorMode {
+	case ColorModeCT:
+		mode, value = FlowModeCT, state.ColorTemp
+	case ColorModeHSV:
+		r, g, b := HSVToRGB(float64(state.Hue), float64(state.Saturation)/100, 1)
+		value = int(r)<<16 | int(g)<<8 | int(b)
+	}
+
+	// One step per brightness level, as long as the steps don't get shorter
+	// than the light bulb supports.
+	steps := target - state.Brightness
+	if steps < 0 {
+		steps = -steps
+	}
+	maxSteps := int(d / minFlowDuration)
+	if maxSteps < 1 {
+		maxSteps = 1
+	}
+	steps = clamp(steps, 1, maxSteps)
+
+	flow := make([]FlowTuple, steps)
+	for i := range flow {
+		flow[i] = FlowTuple{
+			Duration:   d / time.Duration(steps),
+			Mode:       mode,
+			Value:      value,
+			Brightness: state.Brightness + (target-state.Brightness)*(i+1)/steps,
+		}
+		if flow[i].Duration < minFlowDuration {
+			flow[i].Duration = minFlowDuration
+		}
+	}
+	return flow, nil
+}
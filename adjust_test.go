@@ -0,0 +1,82 @@
+package yeelight
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBrightenWithinBounds(t *testing.T) {
+	tests := []struct {
+		current, step int
+		want          float64
+	}{
+		{current: 50, step: 10, want: 50},
+		{current: 25, step: 10, want: 30},
+		{current: 30, step: -10, want: 25},
+		{current: 1, step: -10, want: 1},
+	}
+	for _, tt := range tests {
+		b, srv := mockBulb(t, WithBrightnessBounds(1, 50))
+		handleProps(srv, map[string]string{"power": "on", "bright": strconv.Itoa(tt.current)})
+
+		err := b.Brighten(tt.step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := lastCommand(t, srv, "set_bright").Params[0]
+		if got != tt.want {
+			t.Errorf("Brighten(%d) at %d sent %v, want %v", tt.step, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestFadeBrightnessWithinBounds(t *testing.T) {
+	b, srv := mockBulb(t, WithBrightnessBounds(1, 50))
+	handleProps(srv, map[string]string{"power": "on", "bright": "10", "ct": "4000", "color_mode": "2"})
+
+	err := b.FadeBrightness(100, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow, err := ParseFlowExpression(lastCommand(t, srv, "start_cf").Params[2].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range flow {
+		if step.Brightness > 50 {
+			t.Fatalf("fade step exceeds the bounds: %+v", step)
+		}
+	}
+	if last := flow[len(flow)-1].Brightness; last != 50 {
+		t.Errorf("fade ends at %d, want 50", last)
+	}
+}
+
+func TestBrightnessBoundsRoundTrip(t *testing.T) {
+	b := newBulb("", []Option{WithBrightnessBounds(10, 60)})
+	for v := 1; v <= 100; v++ {
+		physical := b.remapBrightness(v)
+		if physical < 10 || physical > 60 {
+			t.Fatalf("remapBrightness(%d) = %d out of bounds", v, physical)
+		}
+		if back := b.remapBrightness(b.unmapBrightness(physical)); back != physical {
+			t.Errorf("round trip of %d: %d != %d", v, back, physical)
+		}
+	}
+}
+
+func TestNudgeUnsupported(t *testing.T) {
+	b, srv := mockBulb(t)
+	srv.Reject("adjust_bright", -1, "unsupported method")
+
+	err := b.NudgeBrightness(10, time.Second)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("got %v, want ErrUnsupported", err)
+	}
+	if !strings.Contains(err.Error(), "requires a newer firmware") {
+		t.Errorf("got %q, want a hint to update the firmware", err)
+	}
+}
@@ -0,0 +1,49 @@
+package yeelight
+
+// The methods below control the background light of fixtures with two lights,
+// e.g. ceiling lamps with an ambient ring. On light bulbs without a background
+// light they return ErrUnsupported.
+
+// BackgroundPower will turn the background light on or off.
+func (b *Bulb) BackgroundPower(on bool) error {
+	power := "off"
+	if on {
+		power = "on"
+	}
+	return b.sendBackground(MethodBgSetPower, power)
+}
+
+// BackgroundRGB will set the background lights red, green and blue values.
+func (b *Bulb) BackgroundRGB(red, green, blue int) error {
+	red = clamp(red, 0, 255)
+	green = clamp(green, 0, 255)
+	blue = clamp(blue, 0, 255)
+	return b.sendBackground(MethodBgSetRGB, red<<16+green<<8+blue)
+}
+
+// BackgroundColorTemp will set the background lights color temperature.
+// Values outside of the range supported by the background light of the model,
+// which can differ from the one of the main light, are clamped.
+func (b *Bulb) BackgroundColorTemp(temp int) error {
+	return b.sendBackground(MethodBgSetCTABX, clamp(temp, b.bgCtMin, b.bgCtMax))
+}
+
+// BackgroundBrightness will set the background lights brightness.
+func (b *Bulb) BackgroundBrightness(brightness int) error {
+	return b.sendBackground(MethodBgSetBright, clamp(brightness, 1, 100))
+}
+
+// BackgroundToggle will toggle the background light.
+func (b *Bulb) BackgroundToggle() error {
+	return b.sendBackground(MethodBgToggle)
+}
+
+// DeviceToggle will toggle the main and background light at once.
+func (b *Bulb) DeviceToggle() error {
+	return b.sendBackground(MethodDevToggle)
+}
+
+func (b *Bulb) sendBackground(method Method, args ...interface{}) error {
+	_, err := b.Send(method, args...)
+	return err
+}
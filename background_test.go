@@ -0,0 +1,47 @@
+package yeelight
+
+import "testing"
+
+func TestBackgroundColorTempRange(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		temp int
+		want float64
+	}{
+		{"default", nil, 1000, defaultColorTempMin},
+		{"model", []Option{withModel("ceiling4")}, 1800, 1800},
+		{"option", []Option{WithBackgroundColorTempRange(2000, 5000)}, 1800, 2000},
+		{"option max", []Option{WithBackgroundColorTempRange(2000, 5000)}, 6000, 5000},
+		{"option overrides model", []Option{withModel("ceiling4"), WithBackgroundColorTempRange(2700, 6500)}, 1800, 2700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, srv := mockBulb(t, tt.opts...)
+			err := b.BackgroundColorTemp(tt.temp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := lastCommand(t, srv, "bg_set_ct_abx").Params[0]
+			if got != tt.want {
+				t.Errorf("BackgroundColorTemp(%d) sent %v, want %v", tt.temp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackgroundRangeIndependentOfMainLight(t *testing.T) {
+	b, srv := mockBulb(t, withModel("ceiling4"))
+	if err := b.ColorTemp(1800); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.BackgroundColorTemp(1800); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommand(t, srv, "set_ct_abx").Params[0]; got != float64(2700) {
+		t.Errorf("main light got %v, want 2700", got)
+	}
+	if got := lastCommand(t, srv, "bg_set_ct_abx").Params[0]; got != float64(1800) {
+		t.Errorf("background light got %v, want 1800", got)
+	}
+}
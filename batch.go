@@ -0,0 +1,90 @@
+package yeelight
+
+import (
+	"context"
+	"fmt"
+)
+
+// Command is a single command of a Batch.
+type Command struct {
+	Method Method
+	Args   []interface{}
+}
+
+// Result is the outcome of a single command of a Batch.
+type Result struct {
+	Values []string
+	Err    error
+}
+
+// Batch sends all commands at once without waiting for the responses in
+// between and collects the responses afterwards. The commands are sent, and
+// executed by the light bulb, in order. The results are returned in the same
+// order, a rejected command doesn't prevent the following ones from being
+// executed. An error is returned if the commands could not be sent, the
+// results then hold the outcome of the commands sent so far.
+func (b *Bulb) Batch(cmds []Command) ([]Result, error) {
+	ctx := context.Background()
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	type call struct {
+		conn  *connection
+		id    int
+		reply <-chan response
+	}
+	calls := make([]call, 0, len(cmds))
+	results := make([]Result, len(cmds))
+
+	var sendErr error
+	for i, cmd := range cmds {
+		if !b.Supports(cmd.Method) {
+			results[i].Err = commandError(0, cmd.Method, ErrUnsupported)
+			calls = append(calls, call{})
+			continue
+		}
+		if b.limiter != nil && !b.inMusicMode() {
+			sendErr = commandError(0, cmd.Method, b.limiter.wait(ctx))
+			if sendErr != nil {
+				break
+			}
+		}
+
+		conn, id, reply, err := b.write(ctx, cmd.Method, cmd.Args)
+		if err != nil {
+			sendErr = commandError(id, cmd.Method, err)
+			break
+		}
+		calls = append(calls, call{conn: conn, id: id, reply: reply})
+	}
+
+	for i, c := range calls {
+		if c.reply == nil {
+			continue
+		}
+		var err error
+		select {
+		case resp := <-c.reply:
+			if resp.Error != nil {
+				err = resp.Error
+			} else {
+				results[i].Values = resp.results()
+			}
+		case <-c.conn.done:
+			err = c.conn.failure()
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		results[i].Err = commandError(c.id, cmds[i].Method, err)
+		c.conn.removePending(c.id)
+	}
+
+	for i := len(calls); i < len(cmds); i++ {
+		// Wrapped so errors.Is finds the reason, e.g. ErrTransport.
+		results[i].Err = commandError(0, cmds[i].Method, fmt.Errorf("not sent: %w", sendErr))
+	}
+	return results, sendErr
+}
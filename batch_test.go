@@ -0,0 +1,37 @@
+package yeelight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBatchNotSent(t *testing.T) {
+	b, _ := mockBulb(t)
+	b.conn.Close()
+	<-b.conn.done
+
+	cmds := []Command{{Method: MethodToggle}, {Method: MethodSetPower, Args: []interface{}{"on"}}}
+	results, err := b.Batch(cmds)
+	if !errors.Is(err, ErrTransport) {
+		t.Fatalf("got %v, want ErrTransport", err)
+	}
+	for i, result := range results {
+		assertCommandError(t, result.Err, cmds[i].Method, ErrTransport)
+	}
+}
+
+func TestBatchResults(t *testing.T) {
+	b, srv := mockBulb(t, WithSupportedMethods(MethodToggle, MethodSetName))
+	srv.Reject("set_name", -1, "unsupported method")
+
+	cmds := []Command{{Method: MethodToggle}, {Method: MethodSetName, Args: []interface{}{"x"}}, {Method: MethodSetDefault}}
+	results, err := b.Batch(cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || len(results[0].Values) != 1 || results[0].Values[0] != "ok" {
+		t.Errorf("toggle: got %+v", results[0])
+	}
+	assertCommandError(t, results[1].Err, MethodSetName, ErrUnsupported)
+	assertCommandError(t, results[2].Err, MethodSetDefault, ErrUnsupported)
+}
@@ -0,0 +1,148 @@
+package yeelight
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SetColor will set the light bulbs color. The alpha channel is ignored, only
+// the color's non-premultiplied red, green and blue values are used. Fully
+// transparent colors are treated as black. With WithReproducibleColors the
+// darkness of the color is reproduced by the brightness, see
+// NearestReproducible.
+func (b *Bulb) SetColor(c color.Color) error {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if !b.reproduce {
+		return b.RGB(int(nrgba.R), int(nrgba.G), int(nrgba.B))
+	}
+	h, s, v := RGBToHSV(nrgba.R, nrgba.G, nrgba.B)
+	hue := int(math.Round(h)) % 360
+	return b.SetScene(HSVScene(hue, int(math.Round(s*100)), int(math.Round(v*100))))
+}
+
+// NearestReproducible returns the color the light bulb shows for c. Light
+// bulbs only reproduce the hue and saturation of a color and always show it
+// at full value, the brightness is set separately. Dark colors therefore turn
+// into their bright counterparts and greys, including black, into white.
+func NearestReproducible(c color.Color) color.RGBA {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	h, s, _ := RGBToHSV(nrgba.R, nrgba.G, nrgba.B)
+	r, g, b := HSVToRGB(h, s, 1)
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// RGBHex will set the light bulbs color from a hex string like "#ff8800". The
+// leading "#" is optional and the shorthand form "#f80" is supported as well.
+func (b *Bulb) RGBHex(hex string) error {
+	red, green, blue, err := parseHex(hex)
+	if err != nil {
+		return err
+	}
+	return b.RGB(red, green, blue)
+}
+
+// parseHex parses a hex color string into its channels.
+func parseHex(hex string) (int, int, int, error) {
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{
+			digits[0], digits[0],
+			digits[1], digits[1],
+			digits[2], digits[2],
+		})
+	}
+	if len(digits) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q: must have 3 or 6 digits", hex)
+	}
+
+	rgb, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q: %+v", hex, err)
+	}
+	return int(rgb >> 16), int(rgb >> 8 & 0xff), int(rgb & 0xff), nil
+}
+
+// HSVToRGB converts a hue between 0 and 360 and a saturation and value
+// between 0 and 1 to red, green and blue. Hues outside the range wrap around,
+// saturations and values outside of it are clamped.
+func HSVToRGB(h, s, v float64) (r, g, b uint8) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	s = math.Max(0, math.Min(1, s))
+	v = math.Max(0, math.Min(1, v))
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var red, green, blue float64
+	switch {
+	case h < 60:
+		red, green, blue = c, x, 0
+	case h < 120:
+		red, green, blue = x, c, 0
+	case h < 180:
+		red, green, blue = 0, c, x
+	case h < 240:
+		red, green, blue = 0, x, c
+	case h < 300:
+		red, green, blue = x, 0, c
+	default:
+		red, green, blue = c, 0, x
+	}
+	return toByte(red + m), toByte(green + m), toByte(blue + m)
+}
+
+// RGBToHSV converts red, green and blue to a hue between 0 and 360 and a
+// saturation and value between 0 and 1. Greys, including black and white,
+// have a hue and saturation of 0.
+func RGBToHSV(r, g, b uint8) (h, s, v float64) {
+	red, green, blue := float64(r)/255, float64(g)/255, float64(b)/255
+	high := math.Max(red, math.Max(green, blue))
+	low := math.Min(red, math.Min(green, blue))
+	delta := high - low
+
+	v = high
+	if high > 0 {
+		s = delta / high
+	}
+	switch {
+	case delta == 0:
+		h = 0
+	case high == red:
+		h = 60 * math.Mod((green-blue)/delta, 6)
+	case high == green:
+		h = 60 * ((blue-red)/delta + 2)
+	default:
+		h = 60 * ((red-green)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// toByte converts a channel between 0 and 1 to a byte.
+func toByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// KelvinToRGB returns an approximation of the color of a black body with the
+// given color temperature, e.g. to preview a color temperature. The
+// temperature is clamped to the range from 1700 to 6500 supported by most
+// light bulbs. The light bulb isn't queried.
+func KelvinToRGB(kelvin int) color.RGBA {
+	t := float64(clamp(kelvin, defaultColorTempMin, defaultColorTempMax)) / 100
+
+	// Black bodies below 6600K have a full red and a blue only above 1900K.
+	green := 99.4708025861*math.Log(t) - 161.1195681661
+	blue := 0.0
+	if t > 19 {
+		blue = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	return color.RGBA{R: 0xff, G: toByte(green / 255), B: toByte(blue / 255), A: 0xff}
+}
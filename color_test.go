@@ -0,0 +1,122 @@
+package yeelight
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		hex     string
+		r, g, b int
+		wantErr bool
+	}{
+		{hex: "#FFF", r: 255, g: 255, b: 255},
+		{hex: "#f80", r: 255, g: 136, b: 0},
+		{hex: "ff8800", r: 255, g: 136, b: 0},
+		{hex: "#FF8800", r: 255, g: 136, b: 0},
+		{hex: "#000000", r: 0, g: 0, b: 0},
+		{hex: "", wantErr: true},
+		{hex: "#", wantErr: true},
+		{hex: "#FFFF", wantErr: true},
+		{hex: "#FF88000", wantErr: true},
+		{hex: "#GGGGGG", wantErr: true},
+		{hex: "+12345", wantErr: true},
+		{hex: "##FFF", wantErr: true},
+	}
+	for _, tt := range tests {
+		r, g, b, err := parseHex(tt.hex)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseHex(%q) = %d, %d, %d, expected an error", tt.hex, r, g, b)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseHex(%q): %v", tt.hex, err)
+			continue
+		}
+		if r != tt.r || g != tt.g || b != tt.b {
+			t.Errorf("parseHex(%q) = %d, %d, %d, want %d, %d, %d", tt.hex, r, g, b, tt.r, tt.g, tt.b)
+		}
+	}
+}
+
+func TestHSVToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, v float64
+		r, g, b uint8
+	}{
+		{h: 0, s: 1, v: 1, r: 255},
+		{h: 360, s: 1, v: 1, r: 255},
+		{h: -120, s: 1, v: 1, b: 255},
+		{h: 120, s: 1, v: 1, g: 255},
+		{h: 0, s: 0, v: 1, r: 255, g: 255, b: 255},
+		{h: 200, s: 0, v: 0.5, r: 128, g: 128, b: 128},
+		{h: 0, s: 0, v: 0},
+		{h: 0, s: 2, v: 2, r: 255},
+	}
+	for _, tt := range tests {
+		r, g, b := HSVToRGB(tt.h, tt.s, tt.v)
+		if r != tt.r || g != tt.g || b != tt.b {
+			t.Errorf("HSVToRGB(%v, %v, %v) = %d, %d, %d, want %d, %d, %d", tt.h, tt.s, tt.v, r, g, b, tt.r, tt.g, tt.b)
+		}
+	}
+}
+
+func TestRGBToHSV(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		h, s, v float64
+	}{
+		{r: 255, h: 0, s: 1, v: 1},
+		{g: 255, h: 120, s: 1, v: 1},
+		{b: 255, h: 240, s: 1, v: 1},
+		{r: 255, b: 255, h: 300, s: 1, v: 1},
+		{r: 255, g: 255, b: 255, h: 0, s: 0, v: 1},
+		{r: 128, g: 128, b: 128, h: 0, s: 0, v: 128.0 / 255},
+		{h: 0, s: 0, v: 0},
+	}
+	for _, tt := range tests {
+		h, s, v := RGBToHSV(tt.r, tt.g, tt.b)
+		if math.Abs(h-tt.h) > 1e-9 || math.Abs(s-tt.s) > 1e-9 || math.Abs(v-tt.v) > 1e-9 {
+			t.Errorf("RGBToHSV(%d, %d, %d) = %v, %v, %v, want %v, %v, %v", tt.r, tt.g, tt.b, h, s, v, tt.h, tt.s, tt.v)
+		}
+	}
+}
+
+func TestHSVRoundTrip(t *testing.T) {
+	for _, c := range [][3]uint8{{255, 0, 0}, {255, 136, 0}, {12, 200, 99}, {128, 128, 128}} {
+		r, g, b := HSVToRGB(RGBToHSV(c[0], c[1], c[2]))
+		if r != c[0] || g != c[1] || b != c[2] {
+			t.Errorf("round trip of %v: got %d, %d, %d", c, r, g, b)
+		}
+	}
+}
+
+func TestKelvinToRGB(t *testing.T) {
+	tests := []struct {
+		kelvin int
+		want   color.RGBA
+	}{
+		{2700, color.RGBA{R: 255, G: 167, B: 87, A: 255}},
+		{4000, color.RGBA{R: 255, G: 206, B: 166, A: 255}},
+		{6500, color.RGBA{R: 255, G: 254, B: 250, A: 255}},
+		{1700, color.RGBA{R: 255, G: 121, A: 255}},
+		{10000, color.RGBA{R: 255, G: 254, B: 250, A: 255}},
+	}
+	for _, tt := range tests {
+		if got := KelvinToRGB(tt.kelvin); got != tt.want {
+			t.Errorf("KelvinToRGB(%d) = %v, want %v", tt.kelvin, got, tt.want)
+		}
+	}
+
+	// Warmer temperatures are redder.
+	for k := 1800; k <= 6500; k += 100 {
+		warm, cool := KelvinToRGB(k-100), KelvinToRGB(k)
+		if warm.G > cool.G || warm.B > cool.B {
+			t.Errorf("KelvinToRGB(%d) = %v is cooler than KelvinToRGB(%d) = %v", k-100, warm, k, cool)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package yeelight
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// connection is a single connection to the light bulb. Its reader goroutine
+// delivers responses to the pending commands and stops once the connection
+// fails, recording the reason in err before closing done. Its writer goroutine
+// writes the queued commands one after another until done is closed. Closed
+// is the closed channel of the bulb at the time the connection was attached,
+// since Reconnect replaces the one of the bulb.
+type connection struct {
+	net.Conn
+	closed <-chan struct{}
+	done   chan struct{}
+	err    error
+	queue  chan outgoing
+
+	mu      sync.Mutex
+	pending map[int]chan response
+}
+
+// connect dials the light bulb and starts reading from the new connection.
+func (b *Bulb) connect(ctx context.Context) error {
+	conn, err := b.dialConn(ctx)
+	if err != nil {
+		return err
+	}
+	b.attach(conn)
+	return nil
+}
+
+// dialConn dials the light bulb. It doesn't need the mutex, so a slow dial
+// doesn't block other callers.
+func (b *Bulb) dialConn(ctx context.Context) (net.Conn, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	conn, err := b.dial(ctx, "tcp", b.address)
+	if err != nil {
+		return nil, transportError(fmt.Errorf("could not dial address: %+v", err))
+	}
+	err = b.setKeepAlive(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// setKeepAlive enables keep-alive probes on TCP connections if configured.
+func (b *Bulb) setKeepAlive(conn net.Conn) error {
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok || b.keepAlive <= 0 {
+		return nil
+	}
+	err := tcp.SetKeepAlive(true)
+	if err != nil {
+		return fmt.Errorf("cannot enable keep-alive: %+v", err)
+	}
+	err = tcp.SetKeepAlivePeriod(b.keepAlive)
+	if err != nil {
+		return fmt.Errorf("cannot set keep-alive period: %+v", err)
+	}
+	return nil
+}
+
+// attach starts reading from the connection and uses it for all further
+// commands.
+func (b *Bulb) attach(conn net.Conn) {
+	b.reset()
+	b.conn = &connection{
+		Conn:    conn,
+		closed:  b.closed,
+		done:    make(chan struct{}),
+		queue:   make(chan outgoing),
+		pending: make(map[int]chan response),
+	}
+	b.status.Store(int32(StatusConnected))
+	go b.readLoop(b.conn)
+	go b.writeLoop(b.conn)
+}
+
+// outgoing is a command queued for the writer of a connection. The result of
+// the write is sent on err.
+type outgoing struct {
+	ctx context.Context
+	cmd command
+	err chan error
+}
+
+// reset restarts the command ids for a new connection. Ids only have to be
+// unique per connection and start at 1, since some firmware ignores id 0. It
+// must be called with the mutex held.
+func (b *Bulb) reset() {
+	b.cmdID = 1
+}
+
+// readLoop reads all messages from the connection. Notifications are delivered
+// to Notifications, responses to the command waiting for them.
+func (b *Bulb) readLoop(conn *connection) {
+	defer close(conn.done)
+	defer conn.Close()
+
+	next := readMessage(conn)
+	if b.lenient {
+		next = readJSON(conn)
+	}
+	for {
+		line, err := next()
+		if err != nil {
+			select {
+			case <-conn.closed:
+				conn.err = ErrClosed
+			default:
+				conn.err = err
+				b.status.Store(int32(StatusDisconnected))
+			}
+			return
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		msg, err := decodeMessage(line)
+		if b.logger != nil {
+			b.logger(LogEvent{Direction: Received, ID: msg.ID, Raw: line, Err: err})
+		}
+		if err != nil {
+			// Skip the malformed message, the next line starts a new one.
+			continue
+		}
+
+		if msg.Method == "props" {
+			b.notify(msg.Params)
+			continue
+		}
+		conn.deliver(msg.response)
+	}
+}
+
+// maxMessageSize is the size of the largest message read from the light bulb.
+const maxMessageSize = 1 << 20
+
+// readMessage returns a function reading the next message. A message ends with
+// a newline or with the end of its JSON object, for firmware which omits the
+// \r\n trailer. Malformed messages end with the line and can be skipped.
+func readMessage(conn net.Conn) func() ([]byte, error) {
+	s := bufio.NewScanner(conn)
+	s.Buffer(nil, maxMessageSize)
+	s.Split(splitMessage)
+	return func() ([]byte, error) {
+		if !s.Scan() {
+			if err := s.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return append([]byte(nil), s.Bytes()...), nil
+	}
+}
+
+// splitMessage is a bufio.SplitFunc splitting the data read from the light
+// bulb into messages, see readMessage.
+func splitMessage(data []byte, atEOF bool) (int, []byte, error) {
+	depth := 0
+	inString, escaped := false, false
+	for i, c := range data {
+		switch {
+		case c == '\n':
+			return i + 1, data[:i], nil
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, data[:i+1], nil
+			}
+		}
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// readJSON returns a function reading the next message regardless of the
+// delimiter, if any, following it. Malformed messages can't be skipped this
+// way and fail the connection.
+func readJSON(conn net.Conn) func() ([]byte, error) {
+	dec := json.NewDecoder(conn)
+	return func() ([]byte, error) {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		return raw, err
+	}
+}
+
+// writeLoop writes the queued commands to the connection until its reader
+// stops. Commands whose context is done by the time they are dequeued are
+// dropped without being written.
+func (b *Bulb) writeLoop(conn *connection) {
+	for {
+		select {
+		case out := <-conn.queue:
+			if err := out.ctx.Err(); err != nil {
+				out.err <- err
+				continue
+			}
+			err := b.writeCommand(out.ctx, conn, out.cmd)
+			if err != nil {
+				select {
+				case <-conn.closed:
+					// Close closed the connection while writing.
+					err = ErrClosed
+				default:
+				}
+			}
+			out.err <- err
+		case <-conn.done:
+			return
+		}
+	}
+}
+
+// enqueue passes a command to the writer of the connection and waits until
+// it is written.
+func (c *connection) enqueue(ctx context.Context, cmd command) error {
+	out := outgoing{ctx: ctx, cmd: cmd, err: make(chan error, 1)}
+	select {
+	case c.queue <- out:
+	case <-c.done:
+		return c.failure()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-out.err:
+		return err
+	case <-ctx.Done():
+		// The writer gives up as well once the deadline of the context is
+		// exceeded.
+		return ctx.Err()
+	}
+}
+
+// decodeMessage decodes a single message.
+func decodeMessage(line []byte) (message, error) {
+	var msg message
+	err := json.Unmarshal(line, &msg)
+	return msg, err
+}
+
+// writeCommand writes a single command to the connection.
+func (b *Bulb) writeCommand(ctx context.Context, conn net.Conn, cmd command) error {
+	// A zero deadline clears the one of the previous command.
+	deadline, _ := ctx.Deadline()
+	err := conn.SetWriteDeadline(deadline)
+	if err != nil {
+		return transportError(fmt.Errorf("cannot set deadline: %+v", err))
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("cannot encode json: %+v", err)
+	}
+
+	// Messages are delimited by \r\n, write them at once so the light bulb
+	// never sees a partial message followed by a pause.
+	_, err = conn.Write(append(data, "\r\n"...))
+	if err != nil {
+		err = contextError(ctx, transportError(fmt.Errorf("cannot write json: %+v", err)))
+	}
+	if b.logger != nil {
+		b.logger(LogEvent{Direction: Sent, ID: cmd.ID, Raw: data, Err: err})
+	}
+	return err
+}
+
+// addPending registers a command waiting for its response.
+func (c *connection) addPending(id int) <-chan response {
+	reply := make(chan response, 1)
+	c.mu.Lock()
+	c.pending[id] = reply
+	c.mu.Unlock()
+	return reply
+}
+
+// removePending stops waiting for the response of a command.
+func (c *connection) removePending(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// deliver passes a response to the command waiting for it. Responses of
+// commands no one waits for anymore are dropped.
+func (c *connection) deliver(resp response) {
+	c.mu.Lock()
+	reply, ok := c.pending[resp.ID]
+	delete(c.pending, resp.ID)
+	c.mu.Unlock()
+
+	if ok {
+		reply <- resp
+	}
+}
+
+// failure returns the reason the connection failed, ErrClosed if the bulb was
+// closed. It must only be called once done is closed.
+func (c *connection) failure() error {
+	if c.err == ErrClosed {
+		return ErrClosed
+	}
+	return transportError(fmt.Errorf("connection failed: %+v", c.err))
+}
+
+// close closes the connection and waits for the reader to stop.
+func (c *connection) close() error {
+	select {
+	case <-c.done:
+		// The reader already closed the failed connection.
+		return nil
+	default:
+	}
+	err := c.Close()
+	<-c.done
+	return err
+}
@@ -0,0 +1,47 @@
+package yeelight
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// sockopt returns the value of a socket option of the TCP connection.
+func sockopt(t *testing.T, conn net.Conn, level, opt int) int {
+	t.Helper()
+	raw, err := conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value int
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		value, sockErr = syscall.GetsockoptInt(int(fd), level, opt)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sockErr != nil {
+		t.Fatal(sockErr)
+	}
+	return value
+}
+
+func TestKeepAlive(t *testing.T) {
+	b, _ := mockBulb(t, WithDialer(&net.Dialer{KeepAlive: -1}), WithKeepAlive(42*time.Second))
+	conn := b.conn.Conn
+	if sockopt(t, conn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE) == 0 {
+		t.Error("keep-alive is not enabled on the socket")
+	}
+	if idle := sockopt(t, conn, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE); idle != 42 {
+		t.Errorf("keep-alive idle time is %ds, want 42s", idle)
+	}
+}
+
+func TestKeepAliveDisabled(t *testing.T) {
+	b, _ := mockBulb(t, WithDialer(&net.Dialer{KeepAlive: -1}))
+	if sockopt(t, b.conn.Conn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE) != 0 {
+		t.Error("keep-alive is enabled without WithKeepAlive")
+	}
+}
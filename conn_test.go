@@ -0,0 +1,100 @@
+package yeelight
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{data: "{\"id\":1}\r\n{\"id\":2}\r\n", want: []string{`{"id":1}`, `{"id":2}`}},
+		{data: `{"id":1}{"id":2}`, want: []string{`{"id":1}`, `{"id":2}`}},
+		{data: "{\"id\":1}\r\n\r\n{\"id\":2}", want: []string{`{"id":1}`, `{"id":2}`}},
+		{data: `{"id":1,"result":["}{"]}`, want: []string{`{"id":1,"result":["}{"]}`}},
+		{data: `{"id":1,"result":["\"}"]}`, want: []string{`{"id":1,"result":["\"}"]}`}},
+		{data: "{\"id\":1,\"result\":\r\n{\"id\":2}", want: []string{`{"id":1,"result":`, `{"id":2}`}},
+		{data: "garbage\n{\"id\":2}", want: []string{"garbage", `{"id":2}`}},
+	}
+	for _, tt := range tests {
+		s := bufio.NewScanner(bytes.NewReader([]byte(tt.data)))
+		s.Split(splitMessage)
+		var got []string
+		for s.Scan() {
+			if line := bytes.TrimSpace(s.Bytes()); len(line) > 0 {
+				got = append(got, string(line))
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.data, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %q, want %q", tt.data, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMissingTrailer(t *testing.T) {
+	b, srv := mockBulb(t)
+	srv.OmitTrailers()
+
+	for i := 0; i < 3; i++ {
+		result, err := b.Send(MethodToggle)
+		if err != nil {
+			t.Fatalf("command %d: %v", i+1, err)
+		}
+		if len(result) != 1 || result[0] != "ok" {
+			t.Errorf("command %d: got %q", i+1, result)
+		}
+	}
+}
+
+func TestFragmentedReply(t *testing.T) {
+	for _, lenient := range []bool{false, true} {
+		var opts []Option
+		if lenient {
+			opts = append(opts, WithLenientFraming())
+		}
+		b, srv := mockBulb(t, opts...)
+		srv.FragmentReplies(10)
+
+		result, err := b.Send(MethodToggle)
+		if err != nil {
+			t.Fatalf("lenient %v: %v", lenient, err)
+		}
+		if len(result) != 1 || result[0] != "ok" {
+			t.Errorf("lenient %v: got %q", lenient, result)
+		}
+	}
+}
+
+func TestCoalescedReplies(t *testing.T) {
+	for _, lenient := range []bool{false, true} {
+		var opts []Option
+		if lenient {
+			opts = append(opts, WithLenientFraming())
+		}
+		b, srv := mockBulb(t, opts...)
+		srv.CoalesceReplies(2)
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := b.Send(MethodToggle)
+				errs <- err
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("lenient %v: %v", lenient, err)
+			}
+		}
+	}
+}
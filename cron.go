@@ -0,0 +1,82 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// cronPowerOff is the type of the power off timer.
+const cronPowerOff = 0
+
+// cronJob is returned by the light bulb when querying a timer.
+type cronJob struct {
+	Type  int `json:"type"`
+	Delay int `json:"delay"`
+}
+
+// SetPowerOffTimer will turn the light bulb off after the given duration. The
+// timer has a granularity of minutes, shorter durations are rounded up.
+func (b *Bulb) SetPowerOffTimer(d time.Duration) error {
+	if d <= 0 {
+		return errors.New("timer duration must be positive")
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	_, err := b.Send(MethodCronAdd, cronPowerOff, minutes)
+	return err
+}
+
+// GetPowerOffTimer returns the time left until the light bulb turns off. If no
+// timer is set, zero is returned.
+func (b *Bulb) GetPowerOffTimer() (time.Duration, error) {
+	result, err := b.Send(MethodCronGet, cronPowerOff)
+	if err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+
+	var job cronJob
+	err = DecodeResult(result, &job)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timer: %+v", err)
+	}
+	return time.Duration(job.Delay) * time.Minute, nil
+}
+
+// ClearPowerOffTimer removes the power off timer.
+func (b *Bulb) ClearPowerOffTimer() error {
+	_, err := b.Send(MethodCronDel, cronPowerOff)
+	return err
+}
+
+// TurnOnFor will turn the light bulb on with the given brightness and off
+// again after the given duration. The duration has a granularity of minutes,
+// it must be at least one minute and is rounded up otherwise.
+func (b *Bulb) TurnOnFor(bright int, d time.Duration) error {
+	if d < time.Minute {
+		return errors.New("duration must be at least one minute")
+	}
+	err := b.fit("brightness", &bright, 1, 100)
+	if err != nil {
+		return err
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	return b.SetScene(AutoDelayOffScene(bright, minutes))
+}
+
+// PowerOffRemaining returns the time left until the light bulb turns off, as
+// reported by its delayoff property. It covers both the power off timer and
+// auto delay off scenes. If no timer is active, zero is returned.
+func (b *Bulb) PowerOffRemaining() (time.Duration, error) {
+	props, err := b.GetProp("delayoff")
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := parseIntProp(props["delayoff"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid delayoff property: %+v", err)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
@@ -0,0 +1,362 @@
+package yeelight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// discoveryAddress is the multicast group the light bulbs listen on.
+const discoveryAddress = "239.255.255.250:1982"
+
+// searchMessage is the SSDP search request sent to the multicast group.
+const searchMessage = "M-SEARCH * HTTP/1.1\r\n" +
+	"HOST: " + discoveryAddress + "\r\n" +
+	"MAN: \"ssdp:discover\"\r\n" +
+	"ST: wifi_bulb\r\n"
+
+// probeInterval is the time between two search requests of DiscoverStream.
+const probeInterval = 5 * time.Second
+
+// BulbInfo describes a light bulb found on the local network.
+type BulbInfo struct {
+	ID               string
+	Model            string
+	FirmwareVersion  string
+	Location         string
+	Address          string
+	Power            bool
+	Brightness       int
+	SupportedMethods []string
+
+	bulb *Bulb
+}
+
+// Connect opens a connection to the discovered light bulb. Methods not
+// supported by the light bulb fail with ErrUnsupported without being sent and
+// the color temperature range is derived from the model. Options are passed
+// on to NewBulb and take precedence.
+func (bi *BulbInfo) Connect(opts ...Option) (*Bulb, error) {
+	defaults := []Option{withModel(bi.Model)}
+	if len(bi.SupportedMethods) > 0 {
+		methods := make([]Method, len(bi.SupportedMethods))
+		for i, method := range bi.SupportedMethods {
+			methods[i] = Method(method)
+		}
+		defaults = append(defaults, WithSupportedMethods(methods...))
+	}
+	opts = append(defaults, opts...)
+	return NewBulb(bi.Address, opts...)
+}
+
+// Refresh queries the current power and brightness of the light bulb and
+// updates the info. The connection is kept for further refreshes until Close
+// is called, it is dropped if a refresh fails. Refresh must not be called
+// concurrently.
+func (bi *BulbInfo) Refresh() error {
+	if bi.bulb == nil {
+		bulb, err := bi.Connect()
+		if err != nil {
+			return err
+		}
+		bi.bulb = bulb
+	}
+
+	props, err := bi.bulb.GetProp("power", "bright")
+	if err == nil {
+		bi.Power = props["power"] == "on"
+		bi.Brightness, err = parseIntProp(props["bright"])
+	}
+	if err != nil {
+		bi.Close()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection kept by Refresh, if any.
+func (bi *BulbInfo) Close() error {
+	if bi.bulb == nil {
+		return nil
+	}
+	err := bi.bulb.Close()
+	bi.bulb = nil
+	return err
+}
+
+// Discover searches the local network for light bulbs. It sends a search
+// request and collects the answers until the timeout elapses. Every bulb is
+// returned only once, even if it answered multiple times.
+func Discover(timeout time.Duration, opts ...DiscoverOption) ([]*BulbInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	bulbs, err := DiscoverContext(ctx, opts...)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return bulbs, nil
+	}
+	return bulbs, err
+}
+
+// DiscoverContext works like Discover but collects the answers until the
+// context is done. The bulbs found so far are then returned along with the
+// context's error.
+func DiscoverContext(ctx context.Context, opts ...DiscoverOption) ([]*BulbInfo, error) {
+	addr, err := net.ResolveUDPAddr("udp4", discoveryAddress)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve multicast address: %+v", err)
+	}
+
+	conn, err := listenDiscovery(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_, err = conn.WriteTo([]byte(searchMessage), addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot send search request: %+v", err)
+	}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		// A deadline in the past unblocks the pending read.
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Now())
+		case <-stopped:
+		}
+	}()
+
+	var bulbs []*BulbInfo
+	seen := make(map[string]bool)
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return bulbs, ctx.Err()
+			}
+			return bulbs, fmt.Errorf("receiving search response: %+v", err)
+		}
+
+		info, err := parseBulbInfo(string(buf[:n]))
+		if err != nil || seen[info.ID] {
+			continue
+		}
+		seen[info.ID] = true
+		bulbs = append(bulbs, info)
+	}
+}
+
+// DiscoverStream searches the local network for light bulbs until the context
+// is canceled. The search request is repeated periodically and every bulb is
+// sent on the returned channel when it is seen for the first time and
+// whenever its announced info changes. The channel is closed once the context
+// is canceled or the search fails.
+func DiscoverStream(ctx context.Context, opts ...DiscoverOption) (<-chan *BulbInfo, error) {
+	addr, err := net.ResolveUDPAddr("udp4", discoveryAddress)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve multicast address: %+v", err)
+	}
+
+	conn, err := listenDiscovery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.WriteTo([]byte(searchMessage), addr)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot send search request: %+v", err)
+	}
+
+	bulbs := make(chan *BulbInfo)
+	stopped := make(chan struct{})
+	go func() {
+		// Closing the connection unblocks the pending read.
+		select {
+		case <-ctx.Done():
+		case <-stopped:
+		}
+		conn.Close()
+	}()
+
+	go func() {
+		defer close(bulbs)
+		defer close(stopped)
+
+		seen := make(map[string]*BulbInfo)
+		buf := make([]byte, 4096)
+		next := time.Now().Add(probeInterval)
+		for {
+			err := conn.SetReadDeadline(next)
+			if err != nil {
+				return
+			}
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() || ctx.Err() != nil {
+					return
+				}
+				_, err = conn.WriteTo([]byte(searchMessage), addr)
+				if err != nil {
+					return
+				}
+				next = time.Now().Add(probeInterval)
+				continue
+			}
+
+			info, err := parseBulbInfo(string(buf[:n]))
+			if err != nil || reflect.DeepEqual(seen[info.ID], info) {
+				continue
+			}
+			seen[info.ID] = info
+			select {
+			case bulbs <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return bulbs, nil
+}
+
+// DiscoverOption configures the search of Discover, DiscoverContext and
+// DiscoverStream.
+type DiscoverOption func(*discoverConfig)
+
+type discoverConfig struct {
+	source net.IP
+	iface  string
+}
+
+// WithDiscoverySource sends the search request from the given local IPv4
+// address, which selects the network searched on hosts attached to multiple
+// networks. By default the operating system chooses.
+func WithDiscoverySource(ip net.IP) DiscoverOption {
+	return func(c *discoverConfig) {
+		c.source = ip
+	}
+}
+
+// WithDiscoveryInterface sends the search request from the first IPv4
+// address of the named network interface, e.g. "wlan0".
+func WithDiscoveryInterface(name string) DiscoverOption {
+	return func(c *discoverConfig) {
+		c.iface = name
+	}
+}
+
+// listenDiscovery opens the socket the search request is sent from.
+func listenDiscovery(opts []DiscoverOption) (*net.UDPConn, error) {
+	var c discoverConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.iface != "" {
+		ip, err := interfaceIPv4(c.iface)
+		if err != nil {
+			return nil, err
+		}
+		c.source = ip
+	}
+
+	var local *net.UDPAddr
+	if c.source != nil {
+		local = &net.UDPAddr{IP: c.source}
+	}
+	conn, err := net.ListenUDP("udp4", local)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen: %+v", err)
+	}
+	return conn, nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of the named interface.
+func interfaceIPv4(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown interface %q: %+v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list addresses of %q: %+v", name, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("interface %q has no IPv4 address", name)
+}
+
+// parseBulbInfo parses the headers of a search response.
+func parseBulbInfo(msg string) (*BulbInfo, error) {
+	lines := strings.Split(msg, "\r\n")
+	if !strings.HasPrefix(lines[0], "HTTP/1.1 200") {
+		return nil, fmt.Errorf("unexpected status line: %q", lines[0])
+	}
+
+	headers := make(map[string]string)
+	for _, line := range lines[1:] {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	if headers["id"] == "" {
+		return nil, errors.New("missing id header")
+	}
+
+	address, err := parseLocation(headers["location"])
+	if err != nil {
+		return nil, err
+	}
+
+	brightness, _ := strconv.Atoi(headers["bright"])
+	return &BulbInfo{
+		ID:               headers["id"],
+		Model:            headers["model"],
+		FirmwareVersion:  headers["fw_ver"],
+		Location:         headers["location"],
+		Address:          address,
+		Power:            headers["power"] == "on",
+		Brightness:       brightness,
+		SupportedMethods: strings.Fields(headers["support"]),
+	}, nil
+}
+
+// parseLocation returns the address of a location like
+// "yeelight://192.168.1.50:55443". The scheme and any path are optional, the
+// default port is used if the port is missing. Locations without an IP
+// address or with an invalid port are rejected.
+func parseLocation(location string) (string, error) {
+	address := strings.TrimSpace(location)
+	if _, rest, ok := strings.Cut(address, "://"); ok {
+		address = rest
+	}
+	address, _, _ = strings.Cut(address, "/")
+	address = withDefaultPort(address)
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid location %q: %+v", location, err)
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid location %q: no IP address", location)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", fmt.Errorf("invalid location %q: invalid port", location)
+	}
+	return address, nil
+}
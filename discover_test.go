@@ -0,0 +1,51 @@
+package yeelight
+
+import "testing"
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+		wantErr  bool
+	}{
+		{location: "yeelight://192.168.1.50:55443", want: "192.168.1.50:55443"},
+		{location: "  yeelight://192.168.1.50:55443\t", want: "192.168.1.50:55443"},
+		{location: "yeelight://192.168.1.50", want: "192.168.1.50:55443"},
+		{location: "yeelight://192.168.1.50:1234/", want: "192.168.1.50:1234"},
+		{location: "yeelight://192.168.1.50/path", want: "192.168.1.50:55443"},
+		{location: "192.168.1.50:55443", want: "192.168.1.50:55443"},
+		{location: "yeelight://[fe80::1]:55443", want: "[fe80::1]:55443"},
+		{location: "yeelight://[fe80::1]", want: "[fe80::1]:55443"},
+		{location: "", wantErr: true},
+		{location: "yeelight://", wantErr: true},
+		{location: "garbage", wantErr: true},
+		{location: "yeelight://bulb.local:55443", wantErr: true},
+		{location: "yeelight://192.168.1.50:", wantErr: true},
+		{location: "yeelight://192.168.1.50:port", wantErr: true},
+		{location: "yeelight://192.168.1.50:70000", wantErr: true},
+		{location: "yeelight://192.168.1.50:1:2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLocation(tt.location)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseLocation(%q) = %q, expected an error", tt.location, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseLocation(%q): %v", tt.location, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseLocation(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestParseBulbInfoInvalidLocation(t *testing.T) {
+	msg := "HTTP/1.1 200 OK\r\nLocation: garbage\r\nid: 0x1\r\n"
+	if _, err := parseBulbInfo(msg); err == nil {
+		t.Error("expected an error for an invalid location")
+	}
+}
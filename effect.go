@@ -0,0 +1,117 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Effect describes how the light bulb changes to a new setting.
+type Effect string
+
+var (
+	// Sudden changes the setting immediately.
+	Sudden Effect = "sudden"
+	// Smooth fades to the new setting over a duration.
+	Smooth Effect = "smooth"
+)
+
+// PowerMode describes the mode the light bulb is turned on in.
+type PowerMode int
+
+var (
+	PowerModeNormal    PowerMode = 0
+	PowerModeCT        PowerMode = 1
+	PowerModeRGB       PowerMode = 2
+	PowerModeHSV       PowerMode = 3
+	PowerModeColorFlow PowerMode = 4
+	// PowerModeNight turns the light bulb on in moonlight mode. It is only
+	// supported by light bulbs with a moonlight, e.g. ceiling lamps.
+	PowerModeNight PowerMode = 5
+)
+
+// settlePoll is the time between two power queries while waiting for the
+// light bulb to turn on, see WithPowerOnSettleDelay.
+const settlePoll = 100 * time.Millisecond
+
+// minEffectDuration is the shortest duration supported by smooth effects.
+const minEffectDuration = 30 * time.Millisecond
+
+// effectArgs returns the effect and duration parameters of a command. The
+// duration is raised to the supported minimum for smooth effects and ignored
+// for sudden ones.
+func effectArgs(effect Effect, d time.Duration) []interface{} {
+	if effect != Smooth {
+		return []interface{}{Sudden, 0}
+	}
+	if d < minEffectDuration {
+		d = minEffectDuration
+	}
+	return []interface{}{Smooth, d.Milliseconds()}
+}
+
+// TurnOnWithEffect will turn the light bulb on using the given effect.
+func (b *Bulb) TurnOnWithEffect(effect Effect, d time.Duration) error {
+	return b.setPower("on", effectArgs(effect, d)...)
+}
+
+// TurnOnMode will turn the light bulb on in the given mode using the given
+// effect. PowerModeRGB and PowerModeHSV require a color light bulb,
+// PowerModeNight a light bulb with a moonlight.
+func (b *Bulb) TurnOnMode(mode PowerMode, effect Effect, d time.Duration) error {
+	if mode < PowerModeNormal || mode > PowerModeNight {
+		return fmt.Errorf("invalid power mode: %d", mode)
+	}
+	return b.setPower("on", append(effectArgs(effect, d), mode)...)
+}
+
+// TurnOffWithEffect will turn the light bulb off using the given effect.
+func (b *Bulb) TurnOffWithEffect(effect Effect, d time.Duration) error {
+	return b.setPower("off", effectArgs(effect, d)...)
+}
+
+// ColorTempWithEffect will set the light bulbs color temperature using the
+// given effect.
+func (b *Bulb) ColorTempWithEffect(temp int, effect Effect, d time.Duration) error {
+	return b.colorTemp(temp, effectArgs(effect, d)...)
+}
+
+// RGBWithEffect will set the light bulbs red, green and blue values using the
+// given effect.
+func (b *Bulb) RGBWithEffect(red, green, blue int, effect Effect, d time.Duration) error {
+	return b.rgb(red, green, blue, effectArgs(effect, d)...)
+}
+
+// HSVWithEffect will set the light bulbs hue and saturation using the given
+// effect.
+func (b *Bulb) HSVWithEffect(hue, sat int, effect Effect, d time.Duration) error {
+	return b.hsv(hue, sat, effectArgs(effect, d)...)
+}
+
+// BrightnessWithEffect will set the light bulbs brightness using the given
+// effect.
+func (b *Bulb) BrightnessWithEffect(brightness int, effect Effect, d time.Duration) error {
+	return b.brightness(brightness, effectArgs(effect, d)...)
+}
+
+// SetWhite will set the light bulbs color temperature and brightness in a
+// single command, so both change at once. A sudden effect uses a ct scene,
+// which doesn't support durations, a smooth effect a single step color flow
+// staying at its end.
+func (b *Bulb) SetWhite(ct, bright int, effect Effect, d time.Duration) error {
+	err := errors.Join(
+		b.fit("color temperature", &ct, b.ctMin, b.ctMax),
+		b.fit("brightness", &bright, 1, 100),
+	)
+	if err != nil {
+		return err
+	}
+	if effect != Smooth {
+		return b.SetScene(CTScene(ct, bright))
+	}
+	if d < minFlowDuration {
+		d = minFlowDuration
+	}
+	flow := []FlowTuple{{Duration: d, Mode: FlowModeCT, Value: ct, Brightness: bright}}
+	return b.SetScene(ColorFlowScene(1, FlowActionStay, flow))
+}
@@ -0,0 +1,181 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlowMode describes what a single step of a color flow changes.
+type FlowMode int
+
+var (
+	FlowModeColor FlowMode = 1
+	FlowModeCT    FlowMode = 2
+	FlowModeSleep FlowMode = 7
+)
+
+// FlowAction describes what the light bulb does after a color flow ended.
+type FlowAction int
+
+var (
+	// FlowActionRecover restores the state from before the flow started.
+	FlowActionRecover FlowAction = 0
+	// FlowActionStay keeps the state of the last flow step.
+	FlowActionStay FlowAction = 1
+	// FlowActionOff turns the light bulb off.
+	FlowActionOff FlowAction = 2
+)
+
+// minFlowDuration is the shortest duration supported for a flow step.
+const minFlowDuration = 50 * time.Millisecond
+
+// FlowTuple is a single step of a color flow. Value is a combined rgb value
+// for FlowModeColor and a color temperature for FlowModeCT. It is ignored,
+// just like Brightness, for FlowModeSleep. A Brightness of -1 keeps the
+// current brightness.
+type FlowTuple struct {
+	Duration   time.Duration
+	Mode       FlowMode
+	Value      int
+	Brightness int
+}
+
+// ColorStep is a flow step changing to the color with the given brightness
+// over the duration. The alpha channel of the color is ignored.
+func ColorStep(d time.Duration, c color.Color, bright int) FlowTuple {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return FlowTuple{
+		Duration:   d,
+		Mode:       FlowModeColor,
+		Value:      int(nrgba.R)<<16 | int(nrgba.G)<<8 | int(nrgba.B),
+		Brightness: bright,
+	}
+}
+
+// CTStep is a flow step changing to the color temperature with the given
+// brightness over the duration.
+func CTStep(d time.Duration, ct, bright int) FlowTuple {
+	return FlowTuple{Duration: d, Mode: FlowModeCT, Value: ct, Brightness: bright}
+}
+
+// SleepStep is a flow step keeping the current setting for the duration.
+func SleepStep(d time.Duration) FlowTuple {
+	return FlowTuple{Duration: d, Mode: FlowModeSleep}
+}
+
+// StartColorFlow starts a color flow on the light bulb. Count is the number of
+// steps to run before the flow stops, 0 runs it infinitely. Action determines
+// what happens once the flow stopped.
+func (b *Bulb) StartColorFlow(count int, action FlowAction, flow []FlowTuple) error {
+	expr, err := flowExpression(flow)
+	if err != nil {
+		return err
+	}
+	_, err = b.Send(MethodStartCF, count, action, expr)
+	return err
+}
+
+// StopColorFlow stops a running color flow. The light bulb then behaves as
+// specified by the flow's action.
+func (b *Bulb) StopColorFlow() error {
+	_, err := b.Send(MethodStopCF)
+	return err
+}
+
+// FlowTuples is a color flow, see StartColorFlow.
+type FlowTuples []FlowTuple
+
+// Expression serializes the flow into the comma separated format used by the
+// light bulb, e.g. for the "flow_params" property. The flow is not validated.
+func (flow FlowTuples) Expression() string {
+	values := make([]string, 0, len(flow)*4)
+	for _, step := range flow {
+		values = append(values,
+			strconv.FormatInt(step.Duration.Milliseconds(), 10),
+			strconv.Itoa(int(step.Mode)),
+			strconv.Itoa(step.Value),
+			strconv.Itoa(step.Brightness),
+		)
+	}
+	return strings.Join(values, ",")
+}
+
+// ParseFlowExpression parses a flow in the comma separated format used by the
+// light bulb. Each step consists of four values: the duration in
+// milliseconds, the mode, the value and the brightness.
+func ParseFlowExpression(expr string) (FlowTuples, error) {
+	fields := strings.Split(expr, ",")
+	if strings.TrimSpace(expr) == "" || len(fields)%4 != 0 {
+		return nil, fmt.Errorf("invalid flow expression: %d values are not a multiple of four", len(fields))
+	}
+
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid flow expression: %+v", err)
+		}
+		values[i] = value
+	}
+
+	flow := make(FlowTuples, len(values)/4)
+	for i := range flow {
+		step := FlowTuple{
+			Duration:   time.Duration(values[i*4]) * time.Millisecond,
+			Mode:       FlowMode(values[i*4+1]),
+			Value:      values[i*4+2],
+			Brightness: values[i*4+3],
+		}
+		err := step.validate()
+		if err != nil {
+			return nil, fmt.Errorf("flow step %d: %+v", i, err)
+		}
+		flow[i] = step
+	}
+	return flow, nil
+}
+
+// validate checks that the values of the step are supported by its mode.
+func (t FlowTuple) validate() error {
+	if t.Duration < minFlowDuration {
+		return fmt.Errorf("duration %s is shorter than %s", t.Duration, minFlowDuration)
+	}
+
+	switch t.Mode {
+	case FlowModeColor:
+		if t.Value < 0 || t.Value > 0xffffff {
+			return fmt.Errorf("invalid rgb value %d", t.Value)
+		}
+	case FlowModeCT:
+		if t.Value < defaultColorTempMin || t.Value > defaultColorTempMax {
+			return fmt.Errorf("invalid color temperature %d", t.Value)
+		}
+	case FlowModeSleep:
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %d", t.Mode)
+	}
+
+	if t.Brightness != -1 && (t.Brightness < 1 || t.Brightness > 100) {
+		return fmt.Errorf("invalid brightness %d", t.Brightness)
+	}
+	return nil
+}
+
+// flowExpression validates the flow and serializes it.
+func flowExpression(flow []FlowTuple) (string, error) {
+	if len(flow) == 0 {
+		return "", errors.New("flow has no steps")
+	}
+	for i, step := range flow {
+		err := step.validate()
+		if err != nil {
+			return "", fmt.Errorf("flow step %d: %+v", i, err)
+		}
+	}
+	return FlowTuples(flow).Expression(), nil
+}
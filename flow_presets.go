@@ -0,0 +1,196 @@
+package yeelight
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"time"
+)
+
+// The presets below return a flow along with a suggested count and action.
+// Their results can be passed to StartColorFlow directly:
+//
+//	bulb.StartColorFlow(yeelight.PoliceFlow())
+
+// PoliceFlow alternates between bright red and blue.
+func PoliceFlow() (int, FlowAction, []FlowTuple) {
+	return 0, FlowActionRecover, []FlowTuple{
+		{Duration: 300 * time.Millisecond, Mode: FlowModeColor, Value: 0xff0000, Brightness: 100},
+		{Duration: 300 * time.Millisecond, Mode: FlowModeColor, Value: 0x0000ff, Brightness: 100},
+	}
+}
+
+// DiscoFlow changes between vivid colors at the given beats per minute.
+func DiscoFlow(bpm int) (int, FlowAction, []FlowTuple) {
+	if bpm < 1 {
+		bpm = 1
+	}
+	beat := time.Minute / time.Duration(bpm)
+	if beat < minFlowDuration {
+		beat = minFlowDuration
+	}
+
+	colors := []int{0xff0000, 0x00ff00, 0x0000ff, 0xffff00, 0xff00ff, 0x00ffff}
+	flow := make([]FlowTuple, len(colors))
+	for i, rgb := range colors {
+		flow[i] = FlowTuple{Duration: beat, Mode: FlowModeColor, Value: rgb, Brightness: 100}
+	}
+	return 0, FlowActionRecover, flow
+}
+
+// CandleFlow flickers a warm white like a candle.
+func CandleFlow() (int, FlowAction, []FlowTuple) {
+	steps := []struct {
+		ms, brightness int
+	}{
+		{800, 50}, {800, 30}, {1200, 80}, {800, 60}, {1300, 90},
+		{2400, 50}, {1200, 80}, {800, 60}, {400, 70},
+	}
+	flow := make([]FlowTuple, len(steps))
+	for i, step := range steps {
+		flow[i] = FlowTuple{
+			Duration:   time.Duration(step.ms) * time.Millisecond,
+			Mode:       FlowModeCT,
+			Value:      2700,
+			Brightness: step.brightness,
+		}
+	}
+	return 0, FlowActionRecover, flow
+}
+
+// SunriseFlow slowly ramps from a dim deep warm tone up to a bright cool white
+// over the given duration. The light bulb stays at the final state.
+func SunriseFlow(d time.Duration) (int, FlowAction, []FlowTuple) {
+	half := d / 2
+	if half < minFlowDuration {
+		half = minFlowDuration
+	}
+	flow := []FlowTuple{
+		{Duration: minFlowDuration, Mode: FlowModeColor, Value: 0xff4d00, Brightness: 1},
+		{Duration: half, Mode: FlowModeCT, Value: 1700, Brightness: 10},
+		{Duration: half, Mode: FlowModeCT, Value: 6500, Brightness: 100},
+	}
+	return len(flow), FlowActionStay, flow
+}
+
+// SunsetFlow slowly dims from the current state down to a dim deep warm tone
+// over the given duration and turns the light bulb off afterwards.
+func SunsetFlow(d time.Duration) (int, FlowAction, []FlowTuple) {
+	half := d / 2
+	if half < minFlowDuration {
+		half = minFlowDuration
+	}
+	flow := []FlowTuple{
+		{Duration: half, Mode: FlowModeCT, Value: 1700, Brightness: 10},
+		{Duration: half, Mode: FlowModeColor, Value: 0xff4d00, Brightness: 1},
+	}
+	return len(flow), FlowActionOff, flow
+}
+
+// Sunrise will simulate a sunrise over the given duration, see SunriseFlow.
+// The light bulb is turned on at the lowest brightness if it is off, so it
+// doesn't flash brightly at the start.
+func (b *Bulb) Sunrise(d time.Duration) error {
+	return b.SetScene(ColorFlowScene(SunriseFlow(d)))
+}
+
+// Sunset will simulate a sunset over the given duration, see SunsetFlow.
+func (b *Bulb) Sunset(d time.Duration) error {
+	return b.SetScene(ColorFlowScene(SunsetFlow(d)))
+}
+
+// RainbowFlow sweeps through the color wheel in the given number of evenly
+// spaced steps, each taking stepDuration. Start it with a count of 0 to loop
+// infinitely.
+func RainbowFlow(steps int, stepDuration time.Duration) []FlowTuple {
+	if steps < 1 {
+		steps = 1
+	}
+	if stepDuration < minFlowDuration {
+		stepDuration = minFlowDuration
+	}
+
+	flow := make([]FlowTuple, steps)
+	for i := range flow {
+		r, g, b := HSVToRGB(float64(i)*360/float64(steps), 1, 1)
+		flow[i] = FlowTuple{
+			Duration:   stepDuration,
+			Mode:       FlowModeColor,
+			Value:      int(r)<<16 | int(g)<<8 | int(b),
+			Brightness: 100,
+		}
+	}
+	return flow
+}
+
+// GradientFlow fades from one color to another in the given number of steps.
+// The first step sets from, the last one to, the whole flow takes the given
+// duration. The alpha channel of the colors is ignored.
+func GradientFlow(from, to color.Color, steps int, d time.Duration) []FlowTuple {
+	if steps < 2 {
+		steps = 2
+	}
+	stepDuration := d / time.Duration(steps)
+	if stepDuration < minFlowDuration {
+		stepDuration = minFlowDuration
+	}
+
+	start := color.NRGBAModel.Convert(from).(color.NRGBA)
+	end := color.NRGBAModel.Convert(to).(color.NRGBA)
+	lerp := func(a, b uint8, t float64) int {
+		return int(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+
+	flow := make([]FlowTuple, steps)
+	for i := range flow {
+		t := float64(i) / float64(steps-1)
+		flow[i] = FlowTuple{
+			Duration:   stepDuration,
+			Mode:       FlowModeColor,
+			Value:      lerp(start.R, end.R, t)<<16 | lerp(start.G, end.G, t)<<8 | lerp(start.B, end.B, t),
+			Brightness: 100,
+		}
+	}
+	return flow
+}
+
+// breathingMinBrightness is the brightness BreathingFlow fades down to.
+const breathingMinBrightness = 10
+
+// BreathingFlow slowly fades the brightness of a color up and down, each
+// cycle taking the given period. The light bulb stays on at the lowest
+// brightness, see BreathingFlowMin. Start it with a count of 0 to loop
+// infinitely. The alpha channel of the color is ignored.
+func BreathingFlow(c color.Color, period time.Duration) []FlowTuple {
+	return BreathingFlowMin(c, period, breathingMinBrightness)
+}
+
+// BreathingFlowMin works like BreathingFlow but fades down to the given
+// brightness, which is clamped to the range from 1 to 100.
+func BreathingFlowMin(c color.Color, period time.Duration, minBright int) []FlowTuple {
+	half := period / 2
+	if half < minFlowDuration {
+		half = minFlowDuration
+	}
+	return []FlowTuple{
+		ColorStep(half, c, 100),
+		ColorStep(half, c, clamp(minBright, 1, 100)),
+	}
+}
+
+// ColorLoop will loop through the colors infinitely at the current
+// brightness, holding each of them for dwell. The alpha channel of the colors
+// is ignored.
+func (b *Bulb) ColorLoop(colors []color.Color, dwell time.Duration) error {
+	if len(colors) == 0 {
+		return errors.New("color loop needs at least one color")
+	}
+	if dwell < minFlowDuration {
+		dwell = minFlowDuration
+	}
+	flow := make([]FlowTuple, 0, len(colors)*2)
+	for _, c := range colors {
+		flow = append(flow, ColorStep(minFlowDuration, c, -1), SleepStep(dwell))
+	}
+	return b.StartColorFlow(0, FlowActionRecover, flow)
+}
@@ -0,0 +1,59 @@
+package yeelight
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestRainbowFlow(t *testing.T) {
+	flow := RainbowFlow(6, time.Second)
+	want := []int{0xFF0000, 0xFFFF00, 0x00FF00, 0x00FFFF, 0x0000FF, 0xFF00FF}
+	if len(flow) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(flow), len(want))
+	}
+	for i, step := range flow {
+		if step.Value != want[i] {
+			t.Errorf("step %d: got %06X, want %06X", i, step.Value, want[i])
+		}
+		if step.Mode != FlowModeColor || step.Brightness != 100 || step.Duration != time.Second {
+			t.Errorf("step %d: got %+v", i, step)
+		}
+	}
+
+	flow = RainbowFlow(0, 0)
+	if len(flow) != 1 || flow[0].Value != 0xFF0000 || flow[0].Duration != minFlowDuration {
+		t.Errorf("RainbowFlow(0, 0) = %+v", flow)
+	}
+}
+
+func TestGradientFlow(t *testing.T) {
+	from := color.RGBA{R: 255, G: 136, B: 0, A: 255}
+	to := color.RGBA{R: 0, G: 0, B: 255, A: 255}
+	flow := GradientFlow(from, to, 5, 5*time.Second)
+	if len(flow) != 5 {
+		t.Fatalf("got %d steps, want 5", len(flow))
+	}
+	if first := flow[0].Value; first != 0xFF8800 {
+		t.Errorf("first step: got %06X, want FF8800", first)
+	}
+	if last := flow[4].Value; last != 0x0000FF {
+		t.Errorf("last step: got %06X, want 0000FF", last)
+	}
+	if mid := flow[2].Value; mid != 0x804480 {
+		t.Errorf("middle step: got %06X, want 804480", mid)
+	}
+	for i, step := range flow {
+		if step.Duration != time.Second {
+			t.Errorf("step %d: got duration %s, want 1s", i, step.Duration)
+		}
+	}
+
+	flow = GradientFlow(from, to, 1, 0)
+	if len(flow) != 2 || flow[0].Value != 0xFF8800 || flow[1].Value != 0x0000FF {
+		t.Errorf("GradientFlow with 1 step = %+v", flow)
+	}
+	if flow[0].Duration != minFlowDuration {
+		t.Errorf("got duration %s, want %s", flow[0].Duration, minFlowDuration)
+	}
+}
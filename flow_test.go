@@ -0,0 +1,45 @@
+package yeelight
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlowExpression(t *testing.T) {
+	tests := []struct {
+		name string
+		flow FlowTuples
+		want string
+	}{
+		{"sleep", FlowTuples{SleepStep(500 * time.Millisecond)}, "500,7,0,0"},
+		{"color", FlowTuples{ColorStep(time.Second, color.RGBA{R: 255, G: 136, A: 255}, 80)}, "1000,1,16746496,80"},
+		{"ct", FlowTuples{CTStep(2*time.Second, 2700, -1)}, "2000,2,2700,-1"},
+		{"mixed", FlowTuples{
+			ColorStep(time.Second, color.RGBA{R: 255, A: 255}, 100),
+			SleepStep(500 * time.Millisecond),
+			CTStep(time.Second, 4000, 50),
+			SleepStep(time.Minute),
+		}, "1000,1,16711680,100,500,7,0,0,1000,2,4000,50,60000,7,0,0"},
+		{"empty", FlowTuples{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.flow.Expression()
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			if len(tt.flow) == 0 {
+				return
+			}
+			parsed, err := ParseFlowExpression(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, tt.flow) {
+				t.Errorf("round trip: got %+v, want %+v", parsed, tt.flow)
+			}
+		})
+	}
+}
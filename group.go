@@ -0,0 +1,173 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"sync"
+	"time"
+)
+
+// Group controls multiple light bulbs at once. Commands are sent to all light
+// bulbs concurrently, each bounded by the timeout of its bulb. A failing light
+// bulb doesn't prevent the others from executing the command.
+type Group struct {
+	bulbs []*Bulb
+}
+
+// NewGroup creates a new Group of the given light bulbs.
+func NewGroup(bulbs ...*Bulb) *Group {
+	return &Group{bulbs: bulbs}
+}
+
+// Bulbs returns the light bulbs of the group.
+func (g *Group) Bulbs() []*Bulb {
+	return g.bulbs
+}
+
+// TurnOn will turn all light bulbs on.
+func (g *Group) TurnOn() error {
+	return g.each((*Bulb).TurnOn)
+}
+
+// TurnOff will turn all light bulbs off.
+func (g *Group) TurnOff() error {
+	return g.each((*Bulb).TurnOff)
+}
+
+// AllOn will turn all light bulbs on, just like TurnOn.
+func (g *Group) AllOn() error {
+	return g.TurnOn()
+}
+
+// AllOff will turn all light bulbs off, just like TurnOff.
+func (g *Group) AllOff() error {
+	return g.TurnOff()
+}
+
+// SyncPower will turn all light bulbs on or off. Unlike TurnOn and TurnOff it
+// queries the power of each light bulb first and only sends the command to
+// the ones which differ, which saves commands of the rate limited protocol.
+func (g *Group) SyncPower(on bool) error {
+	return g.each(func(b *Bulb) error {
+		isOn, err := b.IsOn()
+		if err != nil || isOn == on {
+			return err
+		}
+		if on {
+			return b.TurnOn()
+		}
+		return b.TurnOff()
+	})
+}
+
+// Toggle will toggle all light bulbs.
+func (g *Group) Toggle() error {
+	return g.each((*Bulb).Toggle)
+}
+
+// ColorTemp will set the color temperature of all light bulbs.
+func (g *Group) ColorTemp(temp int) error {
+	return g.each(func(b *Bulb) error {
+		return b.ColorTemp(temp)
+	})
+}
+
+// RGB will set the red, green and blue values of all light bulbs.
+func (g *Group) RGB(red, green, blue int) error {
+	return g.each(func(b *Bulb) error {
+		return b.RGB(red, green, blue)
+	})
+}
+
+// HSV will set the hue and saturation of all light bulbs.
+func (g *Group) HSV(hue, sat int) error {
+	return g.each(func(b *Bulb) error {
+		return b.HSV(hue, sat)
+	})
+}
+
+// SetColor will set the color of all light bulbs.
+func (g *Group) SetColor(c color.Color) error {
+	return g.each(func(b *Bulb) error {
+		return b.SetColor(c)
+	})
+}
+
+// Brightness will set the brightness of all light bulbs.
+func (g *Group) Brightness(brightness int) error {
+	return g.each(func(b *Bulb) error {
+		return b.Brightness(brightness)
+	})
+}
+
+// SetScene will set all light bulbs to the given scene.
+func (g *Group) SetScene(scene Scene) error {
+	return g.each(func(b *Bulb) error {
+		return b.SetScene(scene)
+	})
+}
+
+// StartColorFlow will start a color flow on all light bulbs.
+func (g *Group) StartColorFlow(count int, action FlowAction, flow []FlowTuple) error {
+	return g.each(func(b *Bulb) error {
+		return b.StartColorFlow(count, action, flow)
+	})
+}
+
+// StopColorFlow will stop the color flow on all light bulbs.
+func (g *Group) StopColorFlow() error {
+	return g.each((*Bulb).StopColorFlow)
+}
+
+// FadeAllBrightness will fade the brightness of all light bulbs to target
+// over the given duration, see Bulb.FadeBrightness. The fades are prepared
+// first and then started on all light bulbs at once, so they stay in sync.
+func (g *Group) FadeAllBrightness(target int, d time.Duration) error {
+	errs := make([]error, len(g.bulbs))
+	start := make(chan struct{})
+
+	var prepared, wg sync.WaitGroup
+	for i, b := range g.bulbs {
+		prepared.Add(1)
+		wg.Add(1)
+		go func(i int, b *Bulb) {
+			defer wg.Done()
+			flow, err := b.fadeFlow(target, d)
+			prepared.Done()
+			if err == nil {
+				<-start
+				err = b.StartColorFlow(len(flow), FlowActionStay, flow)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", b.address, err)
+			}
+		}(i, b)
+	}
+	prepared.Wait()
+	close(start)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// each runs fn for all light bulbs concurrently. The errors are joined, each
+// prefixed with the address of the failed light bulb.
+func (g *Group) each(fn func(*Bulb) error) error {
+	errs := make([]error, len(g.bulbs))
+
+	var wg sync.WaitGroup
+	for i, b := range g.bulbs {
+		wg.Add(1)
+		go func(i int, b *Bulb) {
+			defer wg.Done()
+			err := fn(b)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", b.address, err)
+			}
+		}(i, b)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package yeelight
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juliusmh/go-yeelight/yeelighttest"
+)
+
+// mockBulb starts a mock light bulb and connects to it.
+func mockBulb(t *testing.T, opts ...Option) (*Bulb, *yeelighttest.Server) {
+	t.Helper()
+	srv := yeelighttest.NewServer()
+	t.Cleanup(srv.Close)
+	b, err := NewBulb(srv.Addr(), append([]Option{WithTimeout(time.Second)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b, srv
+}
+
+// handleProps makes the mock light bulb answer get_prop with the given
+// property values.
+func handleProps(srv *yeelighttest.Server, props map[string]string) {
+	srv.Handle("get_prop", func(params []interface{}) ([]interface{}, error) {
+		result := make([]interface{}, len(params))
+		for i, prop := range params {
+			result[i] = props[prop.(string)]
+		}
+		return result, nil
+	})
+}
+
+// lastCommand returns the last command of the method received by the mock.
+func lastCommand(t *testing.T, srv *yeelighttest.Server, method string) yeelighttest.Command {
+	t.Helper()
+	cmds := srv.Commands()
+	for i := len(cmds) - 1; i >= 0; i-- {
+		if cmds[i].Method == method {
+			return cmds[i]
+		}
+	}
+	t.Fatalf("no %s command received", method)
+	return yeelighttest.Command{}
+}
+
+// assertCommandError checks that err is a CommandError of the method
+// wrapping target.
+func assertCommandError(t *testing.T, err error, method Method, target error) {
+	t.Helper()
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("%v is no CommandError", err)
+	}
+	if cmdErr.Method != method {
+		t.Errorf("got method %s, want %s", cmdErr.Method, method)
+	}
+	if !errors.Is(err, target) {
+		t.Errorf("%v does not wrap %v", err, target)
+	}
+}
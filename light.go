@@ -0,0 +1,16 @@
+package yeelight
+
+// Light covers the common operations of a light bulb. Code written against it
+// can be tested with a mock instead of a physical device.
+type Light interface {
+	TurnOn() error
+	TurnOff() error
+	Toggle() error
+	RGB(red, green, blue int) error
+	Brightness(brightness int) error
+	ColorTemp(temp int) error
+	State() (*State, error)
+	Close() error
+}
+
+var _ Light = (*Bulb)(nil)
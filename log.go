@@ -0,0 +1,28 @@
+package yeelight
+
+// Direction describes whether a message was sent or received.
+type Direction int
+
+var (
+	Sent     Direction = 0
+	Received Direction = 1
+)
+
+// String implements the fmt.Stringer interface.
+func (d Direction) String() string {
+	if d == Sent {
+		return "sent"
+	}
+	return "received"
+}
+
+// LogEvent describes a message sent to or received from the light bulb. Raw
+// holds the message without its \r\n delimiter for sent messages and as
+// received otherwise. ID is zero for notifications and malformed messages.
+// Err is set if the message could not be sent or decoded.
+type LogEvent struct {
+	Direction Direction
+	ID        int
+	Raw       []byte
+	Err       error
+}
@@ -0,0 +1,115 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// EnableMusicMode switches the light bulb into music mode. The light bulb
+// connects back to a server listening on the local address facing it and
+// receives all further commands on that connection. Music mode is not subject
+// to the rate limit of the light bulb, which makes it suitable for frequent
+// updates. Commands sent in music mode don't receive responses, so Send
+// returns no result and queries like GetProp return empty values.
+func (b *Bulb) EnableMusicMode() error {
+	if b.inMusicMode() {
+		return nil
+	}
+	addr := b.LocalAddr()
+	if addr == nil {
+		return ErrClosed
+	}
+	local, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return errors.New("music mode requires a tcp connection")
+	}
+	host := local.IP.String()
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return fmt.Errorf("could not listen: %+v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	timeout := b.timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	err = ln.(*net.TCPListener).SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("cannot set deadline: %+v", err)
+	}
+
+	accepted := make(chan net.Conn, 1)
+	acceptErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			acceptErr <- err
+			return
+		}
+		accepted <- conn
+	}()
+
+	_, err = b.Send(MethodSetMusic, 1, host, port)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+
+	var conn net.Conn
+	select {
+	case conn = <-accepted:
+	case err := <-acceptErr:
+		ln.Close()
+		return fmt.Errorf("light bulb did not connect: %+v", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil || b.music != nil {
+		// Closed or enabled concurrently in the meantime.
+		conn.Close()
+		ln.Close()
+		if b.conn == nil {
+			return ErrClosed
+		}
+		return nil
+	}
+	b.music = conn
+	b.musicLn = ln
+	return nil
+}
+
+// DisableMusicMode switches the light bulb back to normal mode and closes the
+// music mode connection.
+func (b *Bulb) DisableMusicMode() error {
+	_, err := b.Send(MethodSetMusic, 0)
+
+	b.mu.Lock()
+	b.closeMusic()
+	b.mu.Unlock()
+	return err
+}
+
+// inMusicMode reports whether music mode is enabled.
+func (b *Bulb) inMusicMode() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.music != nil
+}
+
+// closeMusic closes the music mode connection and listener. It must be called
+// with the mutex held.
+func (b *Bulb) closeMusic() {
+	if b.music == nil {
+		return
+	}
+	b.music.Close()
+	b.musicLn.Close()
+	b.music = nil
+	b.musicLn = nil
+}
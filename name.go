@@ -0,0 +1,37 @@
+package yeelight
+
+import (
+	"encoding/base64"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SetName will store a name on the light bulb.
+func (b *Bulb) SetName(name string) error {
+	_, err := b.Send(MethodSetName, name)
+	return err
+}
+
+// GetName returns the name stored on the light bulb.
+func (b *Bulb) GetName() (string, error) {
+	props, err := b.GetProp("name")
+	if err != nil {
+		return "", err
+	}
+	return decodeName(props["name"]), nil
+}
+
+// decodeName decodes names some firmware stores base64 encoded. Names which
+// don't decode to printable text are returned unchanged.
+func decodeName(name string) string {
+	decoded, err := base64.StdEncoding.DecodeString(name)
+	if err != nil || len(decoded) == 0 || !utf8.Valid(decoded) {
+		return name
+	}
+	for _, r := range string(decoded) {
+		if !unicode.IsPrint(r) {
+			return name
+		}
+	}
+	return string(decoded)
+}
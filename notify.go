@@ -0,0 +1,88 @@
+package yeelight
+
+import "encoding/json"
+
+// notificationBuffer is the number of state changes buffered for receivers of
+// Notifications.
+const notificationBuffer = 16
+
+// StateChange is sent by the light bulb whenever its state changes, e.g. by
+// commands from other clients. Props holds the new values of all changed
+// properties keyed by property name.
+type StateChange struct {
+	Props map[string]string
+}
+
+// message is received from the light bulb. It is either a response to a
+// command or a notification.
+type message struct {
+	response
+	Method string                     `json:"method"`
+	Params map[string]json.RawMessage `json:"params"`
+}
+
+// Notifications returns a channel receiving the state changes of the light
+// bulb. State changes are dropped if the channel isn't drained fast enough.
+// The channel is closed once the bulb is closed.
+func (b *Bulb) Notifications() <-chan StateChange {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+	return b.notifications
+}
+
+// OnStateChange registers a function called for every state change of the
+// light bulb. Multiple functions may be registered, they are called in order
+// of registration. The functions are called from a separate goroutine, one
+// state change after another, so they may send commands to the light bulb.
+func (b *Bulb) OnStateChange(fn func(StateChange)) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+	// Always copy, notify iterates over the previous slice without the lock.
+	b.handlers = append(b.handlers[:len(b.handlers):len(b.handlers)], fn)
+}
+
+// notify delivers a state change to the registered functions and to
+// Notifications without blocking the reader.
+func (b *Bulb) notify(params map[string]json.RawMessage) {
+	change := StateChange{Props: make(map[string]string, len(params))}
+	for prop, raw := range params {
+		change.Props[prop] = rawString(raw)
+	}
+
+	b.handlersMu.Lock()
+	if len(b.handlers) > 0 {
+		b.changes = append(b.changes, change)
+		if !b.dispatching {
+			b.dispatching = true
+			go b.dispatch()
+		}
+	}
+	b.handlersMu.Unlock()
+
+	select {
+	case b.notifications <- change:
+	default:
+	}
+}
+
+// dispatch calls the registered functions for the queued state changes until
+// the queue is empty. Only one dispatch runs at a time, so the functions see
+// the state changes in order.
+func (b *Bulb) dispatch() {
+	for {
+		b.handlersMu.Lock()
+		if len(b.changes) == 0 {
+			b.dispatching = false
+			b.handlersMu.Unlock()
+			return
+		}
+		change := b.changes[0]
+		b.changes = b.changes[1:]
+		handlers := b.handlers
+		b.handlersMu.Unlock()
+
+		for _, fn := range handlers {
+			fn(change)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package yeelight
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOnStateChangeSendsCommand(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(0))
+	handleProps(srv, map[string]string{"power": "on"})
+
+	done := make(chan error, 1)
+	b.OnStateChange(func(change StateChange) {
+		// A handler sending a command must not wait for the reader it runs
+		// on.
+		_, err := b.GetProp("power")
+		done <- err
+	})
+
+	err := srv.Notify(map[string]interface{}{"power": "on"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command sent from a handler did not return")
+	}
+}
+
+func TestOnStateChangeOrder(t *testing.T) {
+	b, srv := mockBulb(t)
+
+	changes := make(chan string, 3)
+	b.OnStateChange(func(change StateChange) {
+		time.Sleep(10 * time.Millisecond)
+		changes <- change.Props["bright"]
+	})
+
+	for _, bright := range []int{1, 2, 3} {
+		err := srv.Notify(map[string]interface{}{"bright": bright})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		select {
+		case got := <-changes:
+			if got != want {
+				t.Fatalf("got bright %s, want %s", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("handler not called")
+		}
+	}
+}
+
+func TestCloseWhileHandlerRuns(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(0))
+
+	// Hold the reply back, so the handler blocks until the bulb is closed.
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	srv.Handle("get_prop", func([]interface{}) ([]interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	entered := make(chan struct{})
+	returned := make(chan error, 1)
+	b.OnStateChange(func(StateChange) {
+		close(entered)
+		_, err := b.GetProp("power")
+		returned <- err
+	})
+
+	err := srv.Notify(map[string]interface{}{"power": "on"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-entered
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked by a handler")
+	}
+	if err := <-returned; !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
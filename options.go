@@ -0,0 +1,235 @@
+package yeelight
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DefaultTimeout is the default time a single command may take.
+const DefaultTimeout = 5 * time.Second
+
+// The color temperature range used if the model of the light bulb is unknown.
+const (
+	defaultColorTempMin = 1700
+	defaultColorTempMax = 6500
+)
+
+// colorTempRanges are the color temperature ranges supported by models which
+// differ from the default range.
+var colorTempRanges = map[string][2]int{
+	"ct_bulb":  {2700, 6500},
+	"ceiling":  {2700, 6500},
+	"ceiling1": {2700, 6500},
+	"ceiling2": {2700, 6500},
+	"ceiling3": {2700, 6500},
+	"ceiling4": {2700, 6500},
+	"desklamp": {2700, 6500},
+}
+
+// bgColorTempRanges are the color temperature ranges supported by the
+// background lights of dual-light models. They can be wider than the range of
+// the main light.
+var bgColorTempRanges = map[string][2]int{
+	"ceiling4":  {1700, 6500},
+	"ceiling10": {1700, 6500},
+}
+
+// Option configures a Bulb created by NewBulb.
+type Option func(*Bulb)
+
+// WithTimeout sets the time a single command may take before it is aborted.
+// A timeout of zero disables it.
+func WithTimeout(d time.Duration) Option {
+	return func(b *Bulb) {
+		b.timeout = d
+	}
+}
+
+// DialFunc dials a connection to the light bulb, e.g. through a proxy.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// WithDialer sets the dialer used to connect to the light bulb.
+func WithDialer(d *net.Dialer) Option {
+	return func(b *Bulb) {
+		b.dial = d.DialContext
+	}
+}
+
+// WithDialFunc sets the function used to connect to the light bulb.
+func WithDialFunc(dial DialFunc) Option {
+	return func(b *Bulb) {
+		b.dial = dial
+	}
+}
+
+// WithHeartbeat pings the light bulb every interval, see Ping, so routers
+// don't drop the idle connection and failed connections are noticed, and
+// reconnected if enabled, before the next command is sent.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(b *Bulb) {
+		b.heartbeat = interval
+	}
+}
+
+// WithPowerOnSettleDelay makes the methods turning the light bulb on wait
+// until it reports being on, for at most d, so commands sent right after are
+// not ignored while the light bulb is still turning on. By default they return
+// as soon as the light bulb accepted the command.
+func WithPowerOnSettleDelay(d time.Duration) Option {
+	return func(b *Bulb) {
+		b.onDelay = d
+	}
+}
+
+// WithKeepAlive enables TCP keep-alive probes with the given period on the
+// connections to the light bulb, which detects light bulbs that lost power
+// without closing the connection. Connections which aren't TCP connections
+// are left as they are.
+func WithKeepAlive(period time.Duration) Option {
+	return func(b *Bulb) {
+		b.keepAlive = period
+	}
+}
+
+// WithLenientFraming reads messages from the light bulb regardless of how
+// they are delimited, e.g. messages spanning several lines. By default
+// messages end with a newline or with the end of their JSON object, which
+// tolerates firmware omitting the \r\n trailer and keeps the connection
+// usable after a malformed message. With lenient framing a malformed message
+// fails the connection.
+func WithLenientFraming() Option {
+	return func(b *Bulb) {
+		b.lenient = true
+	}
+}
+
+// WithRateLimit paces the commands sent to the light bulb to perMinute
+// commands per minute. Light bulbs drop commands exceeding their limit of
+// about 60 per minute. Commands are spaced evenly and wait until they may be
+// sent or their context is done. Use music mode instead for frequent updates,
+// it is not limited.
+func WithRateLimit(perMinute int) Option {
+	return func(b *Bulb) {
+		if perMinute > 0 {
+			b.limiter = newRateLimiter(perMinute)
+		}
+	}
+}
+
+// WithLogger sets a function called for every message sent to or received
+// from the light bulb. It is called from the goroutines sending and receiving
+// the messages and must not block.
+func WithLogger(logger func(LogEvent)) Option {
+	return func(b *Bulb) {
+		b.logger = logger
+	}
+}
+
+// WithDefaultEffect sets the effect used by RGB, HSV, ColorTemp and
+// Brightness, e.g. to fade all changes smoothly. The methods taking an effect,
+// like RGBWithEffect, use the effect passed to them instead.
+func WithDefaultEffect(effect Effect, d time.Duration) Option {
+	return func(b *Bulb) {
+		b.effect = effectArgs(effect, d)
+	}
+}
+
+// WithStrictValidation makes RGB, HSV, Brightness and ColorTemp return a
+// ValidationError for values outside of the supported range instead of
+// clamping them.
+func WithStrictValidation() Option {
+	return func(b *Bulb) {
+		b.strict = true
+	}
+}
+
+// WithReproducibleColors makes SetColor set the hue and saturation of the
+// color along with a brightness matching its value in a single command, so
+// dark colors sampled e.g. from a screen stay dark instead of being shown at
+// the current brightness. See NearestReproducible for the color shown.
+func WithReproducibleColors() Option {
+	return func(b *Bulb) {
+		b.reproduce = true
+	}
+}
+
+// WithColorTempRange sets the color temperature range supported by the light
+// bulb. It overrides the range derived from the model of discovered bulbs.
+func WithColorTempRange(min, max int) Option {
+	return func(b *Bulb) {
+		b.ctMin = min
+		b.ctMax = max
+	}
+}
+
+// WithBackgroundColorTempRange sets the color temperature range supported by
+// the background light. It overrides the range derived from the model of
+// discovered bulbs.
+func WithBackgroundColorTempRange(min, max int) Option {
+	return func(b *Bulb) {
+		b.bgCtMin = min
+		b.bgCtMax = max
+	}
+}
+
+// WithZeroBrightnessOff maps a single brightness slider to power and
+// brightness. Brightness and BrightnessWithEffect then turn the light bulb
+// off for a brightness of 0 or less. Other values query the power first,
+// turn the light bulb on if it is off and then set the brightness, so they
+// send one or two extra commands. Without it, a brightness of 0 sets the
+// lowest brightness.
+func WithZeroBrightnessOff() Option {
+	return func(b *Bulb) {
+		b.zeroOff = true
+	}
+}
+
+// WithBrightnessBounds remaps the brightness passed to Brightness,
+// BrightnessWithEffect and SetBrightnessStrict linearly from 1 to 100 into the
+// range from min to max, e.g. to cap a bedroom light at night. Dim, Brighten
+// and FadeBrightness stay within the bounds as well. It is applied by the
+// client only, the light bulb itself can still be set to any brightness, e.g.
+// by other apps. Bounds outside of 1 to 100 are clamped.
+func WithBrightnessBounds(min, max int) Option {
+	return func(b *Bulb) {
+		b.brightMin = clamp(min, 1, 100)
+		b.brightMax = clamp(max, b.brightMin, 100)
+	}
+}
+
+// withModel sets the color temperature ranges supported by the model.
+func withModel(model string) Option {
+	return func(b *Bulb) {
+		if r, ok := colorTempRanges[model]; ok {
+			b.ctMin = r[0]
+			b.ctMax = r[1]
+		}
+		if r, ok := bgColorTempRanges[model]; ok {
+			b.bgCtMin = r[0]
+			b.bgCtMax = r[1]
+		}
+	}
+}
+
+// WithSupportedMethods sets the methods supported by the light bulb, as
+// announced during discovery. Other methods fail with ErrUnsupported without
+// being sent.
+func WithSupportedMethods(methods ...Method) Option {
+	return func(b *Bulb) {
+		b.support = make(map[Method]bool, len(methods))
+		for _, method := range methods {
+			b.support[method] = true
+		}
+	}
+}
+
+// WithAutoReconnect enables reconnecting to the light bulb once the
+// connection failed. The failed command is retried once after reconnecting.
+// Reconnect attempts are retried with an exponential backoff of up to
+// maxBackoff for as long as the command may take.
+func WithAutoReconnect(maxBackoff time.Duration) Option {
+	return func(b *Bulb) {
+		b.maxBackoff = maxBackoff
+	}
+}
@@ -0,0 +1,49 @@
+package yeelight
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Preset names a scene in the preset registry.
+type Preset string
+
+var (
+	// PresetReading is a bright cool white.
+	PresetReading Preset = "reading"
+	// PresetRelax is a dim warm white.
+	PresetRelax Preset = "relax"
+	// PresetMovie is a very dim warm white.
+	PresetMovie Preset = "movie"
+	// PresetEnergize is the brightest and coolest white.
+	PresetEnergize Preset = "energize"
+)
+
+var (
+	presetsMu sync.RWMutex
+	presets   = map[Preset]Scene{
+		PresetReading:  CTScene(5000, 100),
+		PresetRelax:    CTScene(2700, 40),
+		PresetMovie:    CTScene(2000, 5),
+		PresetEnergize: CTScene(6500, 100),
+	}
+)
+
+// RegisterPreset adds a scene to the preset registry. Registering a name
+// again replaces the previous scene, including the built-in ones.
+func RegisterPreset(name string, scene Scene) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	presets[Preset(name)] = scene
+}
+
+// ApplyPreset will set the light bulb to the scene registered for the preset.
+func (b *Bulb) ApplyPreset(p Preset) error {
+	presetsMu.RLock()
+	scene, ok := presets[p]
+	presetsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown preset %q", p)
+	}
+	return b.SetScene(scene)
+}
@@ -0,0 +1,59 @@
+package yeelight
+
+import "fmt"
+
+// AllProps are all properties documented by the protocol. Most light bulbs
+// support only some of them, e.g. the bg_ properties are only supported by
+// light bulbs with a background light.
+var AllProps = []string{
+	"power", "bright", "ct", "rgb", "hue", "sat", "color_mode",
+	"flowing", "delayoff", "flow_params", "music_on", "name",
+	"bg_power", "bg_flowing", "bg_flow_params", "bg_ct", "bg_lmode",
+	"bg_bright", "bg_rgb", "bg_hue", "bg_sat", "nl_br", "active_mode",
+}
+
+// GetAllProps queries all properties of AllProps at once. Properties not
+// supported by the light bulb have an empty value.
+func (b *Bulb) GetAllProps() (map[string]string, error) {
+	return b.GetProp(AllProps...)
+}
+
+// GetProp queries the given properties of the light bulb, e.g. "power" or
+// "bright". The values are returned keyed by property name. Properties not
+// supported by the light bulb have an empty value.
+func (b *Bulb) GetProp(props ...string) (map[string]string, error) {
+	args := make([]interface{}, len(props))
+	for i, prop := range props {
+		args[i] = prop
+	}
+
+	result, err := b.Send(MethodGetProp, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(props))
+	for i, prop := range props {
+		if i < len(result) {
+			values[prop] = result[i]
+		} else {
+			values[prop] = ""
+		}
+	}
+	return values, nil
+}
+
+// IsOn reports whether the light bulb is on.
+func (b *Bulb) IsOn() (bool, error) {
+	props, err := b.GetProp("power")
+	if err != nil {
+		return false, err
+	}
+	switch props["power"] {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid power property: %q", props["power"])
+}
@@ -0,0 +1,65 @@
+package yeelight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket pacing the commands sent to the light bulb.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// rateLimitBurst is the number of commands which may be sent at once before
+// the rate limit spaces them. It is kept small, since any burst adds to the
+// commands sent within a minute.
+const rateLimitBurst = 1
+
+// newRateLimiter creates a rate limiter allowing perMinute commands per
+// minute. Commands are spaced evenly, so no more than perMinute plus
+// rateLimitBurst commands are sent within any minute.
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Minute / time.Duration(perMinute),
+		capacity: rateLimitBurst,
+		tokens:   rateLimitBurst,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a command may be sent or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	// Reserve a token, the bucket goes negative while commands queue up.
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package yeelight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacing(t *testing.T) {
+	// 600 per minute is a command every 100ms.
+	l := newRateLimiter(600)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		err := l.wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only the first command may be sent without waiting.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("5 commands took %v, want at least 400ms", elapsed)
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	// Within a window of a tenth of the interval the full limit must not be
+	// available at once.
+	l := newRateLimiter(60)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	sent := 0
+	for l.wait(ctx) == nil {
+		sent++
+	}
+	if sent > rateLimitBurst {
+		t.Errorf("sent %d commands at once, want at most %d", sent, rateLimitBurst)
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	l := newRateLimiter(60)
+	l.wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	// The canceled command gave its token back.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens < -0.1 {
+		t.Errorf("tokens = %v after refund", l.tokens)
+	}
+}
+
+func TestSendRateLimited(t *testing.T) {
+	b, _ := mockBulb(t, WithRateLimit(600))
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		err := b.Toggle()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("4 commands took %v, want at least 300ms", elapsed)
+	}
+}
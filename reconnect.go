@@ -0,0 +1,339 @@
+package yeelight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// pingTimeout is the time a Ping may take.
+const pingTimeout = 2 * time.Second
+
+// minBackoff is the time waited after the first failed reconnect.
+const minBackoff = 100 * time.Millisecond
+
+// ConnStatus describes the state of the connection to the light bulb.
+type ConnStatus int32
+
+var (
+	StatusConnected    ConnStatus = 0
+	StatusDisconnected ConnStatus = 1
+	StatusReconnecting ConnStatus = 2
+	StatusClosed       ConnStatus = 3
+)
+
+// String implements the fmt.Stringer interface.
+func (s ConnStatus) String() string {
+	switch s {
+	case StatusConnected:
+		return "connected"
+	case StatusDisconnected:
+		return "disconnected"
+	case StatusReconnecting:
+		return "reconnecting"
+	case StatusClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("ConnStatus(%d)", int32(s))
+}
+
+// Status returns the state of the connection to the light bulb.
+func (b *Bulb) Status() ConnStatus {
+	return ConnStatus(b.status.Load())
+}
+
+// Connected reports whether the connection to the light bulb was healthy the
+// last time it was used.
+func (b *Bulb) Connected() bool {
+	return b.Status() == StatusConnected
+}
+
+// Ping checks whether the light bulb is reachable without changing its state.
+// It is safe to call concurrently with other commands.
+func (b *Bulb) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	_, err := b.SendContext(ctx, MethodGetProp, "power")
+	return err
+}
+
+// SendRetry works like SendContext but sends the command up to attempts times
+// while it fails with ErrTransport or runs into the timeout of the bulb,
+// waiting with an exponential backoff in between. The failed connection is
+// replaced before the next attempt, also without WithAutoReconnect.
+// Rejections by the light bulb are returned without retrying. If the context
+// is done while waiting, its error is returned.
+func (b *Bulb) SendRetry(ctx context.Context, attempts int, method Method, args ...interface{}) ([]string, error) {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		b.mu.Lock()
+		conn := b.conn
+		b.mu.Unlock()
+
+		result, err := b.SendContext(ctx, method, args...)
+		if err == nil || attempt >= attempts || !retriable(ctx, err) {
+			return result, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+
+		// If dialing fails, the next attempt fails with ErrTransport and is
+		// retried as well.
+		if b.redial(ctx, conn) == ErrClosed {
+			return nil, commandError(0, method, ErrClosed)
+		}
+	}
+}
+
+// retriable reports whether a command failed with err can be sent again.
+// Commands which ran into the timeout of the bulb, but not the deadline of
+// ctx, are usually stuck on a stalled connection.
+func retriable(ctx context.Context, err error) bool {
+	if errors.Is(err, ErrTransport) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil
+}
+
+// Reconnect closes the connection to the light bulb, if any, and dials its
+// address again. Commands waiting for a response on the old connection fail.
+// A closed bulb can be reconnected as well, its Notifications channel is then
+// replaced by a new one. If dialing fails, the error is returned and a closed
+// bulb stays closed. Close aborts a pending Reconnect.
+func (b *Bulb) Reconnect() error {
+	b.reconnectMu.Lock()
+	defer b.reconnectMu.Unlock()
+
+	b.mu.Lock()
+	old := b.conn
+	if old == nil {
+		b.closed = make(chan struct{})
+		b.handlersMu.Lock()
+		b.notifications = make(chan StateChange, notificationBuffer)
+		b.handlersMu.Unlock()
+		b.reopening = true
+	} else {
+		b.closeMusic()
+	}
+	closed := b.closed
+	b.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+	ctx, cancel := untilClosed(context.Background(), closed)
+	defer cancel()
+	conn, err := b.dialConn(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case old == nil:
+		return b.reopen(closed, conn, err)
+	case b.conn == nil:
+		if conn != nil {
+			conn.Close()
+		}
+		return ErrClosed
+	case err != nil:
+		if b.conn == old {
+			b.status.Store(int32(StatusDisconnected))
+		}
+		return err
+	}
+	return b.replace(old, conn)
+}
+
+// reopen attaches the connection dialed for a closed bulb, unless dialing
+// failed or Close aborted it. It must be called with the mutex held.
+func (b *Bulb) reopen(closed chan struct{}, conn net.Conn, err error) error {
+	b.reopening = false
+	select {
+	case <-closed:
+		// Close aborted the reconnect and closed the channel.
+		if conn != nil {
+			conn.Close()
+		}
+		close(b.notifications)
+		return ErrClosed
+	default:
+	}
+	if err != nil {
+		close(b.closed)
+		close(b.notifications)
+		return err
+	}
+	b.attach(conn)
+	b.startHeartbeat()
+	return nil
+}
+
+// startHeartbeat pings the light bulb periodically until it is closed, if
+// enabled by WithHeartbeat.
+func (b *Bulb) startHeartbeat() {
+	if b.heartbeat <= 0 {
+		return
+	}
+	closed := b.closed
+	go func() {
+		ticker := time.NewTicker(b.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Failures are recorded by LastError and Status.
+				b.Ping()
+			case <-closed:
+				return
+			}
+		}
+	}()
+}
+
+// redial replaces the failed connection, dialing once. If another command
+// already replaced the connection, redial returns immediately.
+func (b *Bulb) redial(ctx context.Context, failed *connection) error {
+	closed, err := b.release(failed)
+	if closed == nil {
+		return err
+	}
+	ctx, cancel := untilClosed(ctx, closed)
+	defer cancel()
+
+	conn, err := b.dialConn(ctx)
+	if err != nil {
+		return b.dialFailed(failed, closed, err)
+	}
+	return b.replacing(failed, conn)
+}
+
+// reconnect replaces the failed connection, retrying with an exponential
+// backoff until it succeeds, the context is done or the bulb is closed. If
+// another command already replaced the connection, reconnect returns
+// immediately. The mutex is not held while dialing or waiting, so Close and
+// other commands aren't blocked.
+func (b *Bulb) reconnect(ctx context.Context, failed *connection) error {
+	closed, err := b.release(failed)
+	if closed == nil {
+		return err
+	}
+	ctx, cancel := untilClosed(ctx, closed)
+	defer cancel()
+
+	b.status.Store(int32(StatusReconnecting))
+	backoff := minBackoff
+	for {
+		conn, err := b.dialConn(ctx)
+		if err == nil {
+			return b.replacing(failed, conn)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return b.dialFailed(failed, closed, transportError(fmt.Errorf("could not reconnect: %+v", err)))
+		}
+		if b.current() != failed {
+			// Another command replaced the connection or the bulb was closed.
+			return b.dialFailed(failed, closed, err)
+		}
+		backoff *= 2
+		if backoff > b.maxBackoff {
+			backoff = b.maxBackoff
+		}
+	}
+}
+
+// release closes the failed connection before it is replaced and returns the
+// closed channel of the bulb. If another command already replaced the
+// connection, it returns nil. If the bulb is closed, it returns ErrClosed.
+func (b *Bulb) release(failed *connection) (chan struct{}, error) {
+	b.mu.Lock()
+	if b.conn == nil {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if b.conn != failed {
+		b.mu.Unlock()
+		return nil, nil
+	}
+	closed := b.closed
+	b.mu.Unlock()
+
+	failed.close()
+	return closed, nil
+}
+
+// replacing attaches the new connection in place of the failed one.
+func (b *Bulb) replacing(failed *connection, conn net.Conn) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.replace(failed, conn)
+}
+
+// replace attaches the new connection in place of the failed one. If the bulb
+// was closed or another command replaced the connection meanwhile, the new
+// connection is closed instead. It must be called with the mutex held.
+func (b *Bulb) replace(failed *connection, conn net.Conn) error {
+	if b.conn == nil {
+		conn.Close()
+		return ErrClosed
+	}
+	if b.conn != failed {
+		conn.Close()
+		return nil
+	}
+	b.attach(conn)
+	return nil
+}
+
+// dialFailed returns the error of a failed attempt to replace the failed
+// connection. The status is only changed if the connection is still the
+// failed one, so a bulb closed or reconnected meanwhile keeps its status.
+func (b *Bulb) dialFailed(failed *connection, closed chan struct{}, err error) error {
+	select {
+	case <-closed:
+		return ErrClosed
+	default:
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.conn == nil:
+		return ErrClosed
+	case b.conn != failed:
+		return nil
+	}
+	b.status.Store(int32(StatusDisconnected))
+	return err
+}
+
+// current returns the connection currently used, or nil if the bulb is
+// closed.
+func (b *Bulb) current() *connection {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conn
+}
+
+// untilClosed returns a context which is canceled along with ctx or once the
+// bulb is closed.
+func untilClosed(ctx context.Context, closed <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,204 @@
+package yeelight
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendRetryReconnects(t *testing.T) {
+	var mu sync.Mutex
+	var conns []net.Conn
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := (&net.Dialer{}).DialContext(ctx, network, address)
+		if err == nil {
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+		return conn, err
+	}
+	b, srv := mockBulb(t, WithDialFunc(dial))
+
+	// Break the connection once, without WithAutoReconnect.
+	mu.Lock()
+	conns[0].Close()
+	mu.Unlock()
+	<-b.conn.done
+
+	_, err := b.SendRetry(context.Background(), 3, MethodToggle)
+	if err != nil {
+		t.Fatalf("SendRetry: %v", err)
+	}
+	mu.Lock()
+	dialed := len(conns)
+	mu.Unlock()
+	if dialed != 2 {
+		t.Errorf("dialed %d times, want 2", dialed)
+	}
+	if n := len(srv.Commands()); n != 1 {
+		t.Errorf("mock received %d commands, want 1", n)
+	}
+	if !b.Connected() {
+		t.Errorf("status is %s after a successful retry", b.Status())
+	}
+}
+
+func TestSendRetryStall(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(100*time.Millisecond))
+	var calls atomic.Int32
+	srv.Handle("toggle", func([]interface{}) ([]interface{}, error) {
+		if calls.Add(1) == 1 {
+			// Stall the first command beyond the timeout of the bulb.
+			time.Sleep(300 * time.Millisecond)
+		}
+		return []interface{}{"ok"}, nil
+	})
+
+	_, err := b.SendRetry(context.Background(), 3, MethodToggle)
+	if err != nil {
+		t.Fatalf("SendRetry: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("mock answered %d commands, want 2", n)
+	}
+}
+
+func TestSendRetryRejected(t *testing.T) {
+	b, srv := mockBulb(t)
+	srv.Reject("toggle", -1, "unsupported method")
+
+	_, err := b.SendRetry(context.Background(), 3, MethodToggle)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("got %v, want ErrUnsupported", err)
+	}
+	if n := len(srv.Commands()); n != 1 {
+		t.Errorf("rejected command was sent %d times, want once", n)
+	}
+}
+
+func TestSendRetryContextDeadline(t *testing.T) {
+	b, srv := mockBulb(t)
+	srv.Handle("toggle", func([]interface{}) ([]interface{}, error) {
+		time.Sleep(200 * time.Millisecond)
+		return []interface{}{"ok"}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := b.SendRetry(ctx, 3, MethodToggle)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if n := len(srv.Commands()); n != 1 {
+		t.Errorf("command was sent %d times after the deadline, want once", n)
+	}
+}
+
+// unreachableAfterFirstDial returns a dial function which connects once and
+// then blocks like an unreachable light bulb until its context is done.
+func unreachableAfterFirstDial() DialFunc {
+	var dialed atomic.Bool
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if dialed.CompareAndSwap(false, true) {
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+}
+
+func TestCloseWhileReconnecting(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(0), WithAutoReconnect(time.Second), WithDialFunc(unreachableAfterFirstDial()))
+	srv.Close()
+	<-b.conn.done
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Send(MethodToggle)
+		errs <- err
+	}()
+	for b.Status() != StatusReconnecting {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Other callers aren't blocked by the reconnect.
+	done := make(chan struct{})
+	go func() {
+		b.Addr()
+		b.CommandID()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Addr blocked by the reconnect")
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by the reconnect")
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send still reconnecting after Close")
+	}
+	if s := b.Status(); s != StatusClosed {
+		t.Errorf("status is %s, want closed", s)
+	}
+}
+
+func TestCloseAbortsReconnect(t *testing.T) {
+	b, _ := mockBulb(t, WithTimeout(0), WithDialFunc(unreachableAfterFirstDial()))
+	b.Close()
+
+	errs := make(chan error, 1)
+	go func() { errs <- b.Reconnect() }()
+	for {
+		b.mu.Lock()
+		reopening := b.reopening
+		b.mu.Unlock()
+		if reopening {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	b.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Reconnect still dialing after Close")
+	}
+	if _, ok := <-b.Notifications(); ok {
+		t.Error("Notifications is still open")
+	}
+	if s := b.Status(); s != StatusClosed {
+		t.Errorf("status is %s, want closed", s)
+	}
+}
+
+func TestReconnectReopens(t *testing.T) {
+	b, _ := mockBulb(t)
+	b.Close()
+	if err := b.Reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Send(MethodToggle); err != nil {
+		t.Errorf("Send after reopening: %v", err)
+	}
+}
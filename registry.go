@@ -0,0 +1,64 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Registry holds light bulbs by name.
+type Registry map[string]*Bulb
+
+// registryEntry is a single light bulb of a registry config.
+type registryEntry struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// LoadRegistry reads a JSON array of light bulbs like
+//
+//	[{"name": "desk", "address": "192.168.1.50"}]
+//
+// and connects to each of them using NewBulb with the given options. If a
+// light bulb can't be connected, the others are closed again and the error
+// is returned.
+func LoadRegistry(r io.Reader, opts ...Option) (Registry, error) {
+	var entries []registryEntry
+	err := json.NewDecoder(r).Decode(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry: %+v", err)
+	}
+
+	reg := make(Registry, len(entries))
+	for _, entry := range entries {
+		if entry.Name == "" {
+			reg.Close()
+			return nil, fmt.Errorf("invalid registry: missing name of %q", entry.Address)
+		}
+		if _, ok := reg[entry.Name]; ok {
+			reg.Close()
+			return nil, fmt.Errorf("invalid registry: duplicate name %q", entry.Name)
+		}
+		b, err := NewBulb(entry.Address, opts...)
+		if err != nil {
+			reg.Close()
+			return nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		reg[entry.Name] = b
+	}
+	return reg, nil
+}
+
+// Close closes all light bulbs of the registry. The errors are joined, each
+// prefixed with the name of the light bulb.
+func (reg Registry) Close() error {
+	var errs []error
+	for name, b := range reg {
+		err := b.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
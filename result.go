@@ -0,0 +1,51 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// DecodeResult assigns the values of a result, e.g. returned by SendRaw, to
+// dest in order. Supported destinations are *string, *int, *float64 and *bool,
+// which accepts "on" and "off" as well as "1" and "0". Other destinations are
+// decoded from JSON, e.g. structs for the objects returned by cron_get. A nil
+// destination skips its value, values without a destination are ignored.
+func DecodeResult(result []string, dest ...interface{}) error {
+	if len(dest) > len(result) {
+		return fmt.Errorf("cannot decode %d values from a result of %d", len(dest), len(result))
+	}
+	for i, d := range dest {
+		err := decodeValue(result[i], d)
+		if err != nil {
+			return fmt.Errorf("result %d: %+v", i, err)
+		}
+	}
+	return nil
+}
+
+// decodeValue assigns a single result value to dest.
+func decodeValue(value string, dest interface{}) error {
+	var err error
+	switch d := dest.(type) {
+	case nil:
+	case *string:
+		*d = value
+	case *int:
+		*d, err = strconv.Atoi(value)
+	case *float64:
+		*d, err = strconv.ParseFloat(value, 64)
+	case *bool:
+		switch value {
+		case "on", "1", "true":
+			*d = true
+		case "off", "0", "false":
+			*d = false
+		default:
+			err = fmt.Errorf("invalid boolean %q", value)
+		}
+	default:
+		err = json.Unmarshal([]byte(value), dest)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package yeelight
+
+import "errors"
+
+// Scene describes a state the light bulb is set to in a single command. The
+// light bulb is turned on if it is off. Use one of the constructors, e.g.
+// ColorScene, to create a Scene.
+type Scene struct {
+	args []interface{}
+	err  error
+}
+
+// ColorScene sets a combined rgb value and brightness.
+func ColorScene(rgb, bright int) Scene {
+	return Scene{args: []interface{}{"color", clamp(rgb, 0, 0xffffff), clamp(bright, 1, 100)}}
+}
+
+// CTScene sets a color temperature and brightness.
+func CTScene(ct, bright int) Scene {
+	return Scene{args: []interface{}{"ct", clamp(ct, 1700, 6500), clamp(bright, 1, 100)}}
+}
+
+// HSVScene sets a hue, saturation and brightness.
+func HSVScene(hue, sat, bright int) Scene {
+	return Scene{args: []interface{}{"hsv", clamp(hue, 0, 359), clamp(sat, 0, 100), clamp(bright, 1, 100)}}
+}
+
+// ColorFlowScene starts a color flow, see StartColorFlow.
+func ColorFlowScene(count int, action FlowAction, flow []FlowTuple) Scene {
+	expr, err := flowExpression(flow)
+	if err != nil {
+		return Scene{err: err}
+	}
+	return Scene{args: []interface{}{"cf", count, action, expr}}
+}
+
+// AutoDelayOffScene sets the brightness and turns the light bulb off after
+// the given number of minutes.
+func AutoDelayOffScene(bright int, minutes int) Scene {
+	return Scene{args: []interface{}{"auto_delay_off", clamp(bright, 1, 100), minutes}}
+}
+
+// SetScene will set the light bulb to the given scene.
+func (b *Bulb) SetScene(scene Scene) error {
+	if scene.err != nil {
+		return scene.err
+	}
+	if len(scene.args) == 0 {
+		return errors.New("empty scene")
+	}
+	_, err := b.Send(MethodSetScene, scene.args...)
+	return err
+}
@@ -0,0 +1,226 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"sync"
+)
+
+// stateWorkers is the number of light bulbs queried at once by StatesOf.
+const stateWorkers = 8
+
+// ColorMode describes which setting determines the light bulbs color.
+type ColorMode int
+
+var (
+	ColorModeRGB ColorMode = 1
+	ColorModeCT  ColorMode = 2
+	ColorModeHSV ColorMode = 3
+)
+
+// String implements the fmt.Stringer interface.
+func (m ColorMode) String() string {
+	switch m {
+	case ColorModeRGB:
+		return "rgb"
+	case ColorModeCT:
+		return "ct"
+	case ColorModeHSV:
+		return "hsv"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+// State is a snapshot of the light bulbs current settings.
+type State struct {
+	Power      bool
+	Brightness int
+	ColorTemp  int
+	RGB        color.RGBA
+	Hue        int
+	Saturation int
+	ColorMode  ColorMode
+}
+
+// String implements the fmt.Stringer interface. It summarizes the state,
+// e.g. "on, CT 4000K, bright 80%".
+func (s State) String() string {
+	power := "off"
+	if s.Power {
+		power = "on"
+	}
+	var setting string
+	switch s.ColorMode {
+	case ColorModeRGB:
+		setting = fmt.Sprintf("RGB #%02x%02x%02x", s.RGB.R, s.RGB.G, s.RGB.B)
+	case ColorModeHSV:
+		setting = fmt.Sprintf("HSV %d/%d%%", s.Hue, s.Saturation)
+	default:
+		setting = fmt.Sprintf("CT %dK", s.ColorTemp)
+	}
+	return fmt.Sprintf("%s, %s, bright %d%%", power, setting, s.Brightness)
+}
+
+// stateProps are the properties queried to build a State.
+var stateProps = []string{"power", "bright", "ct", "rgb", "hue", "sat", "color_mode"}
+
+// State will query the light bulbs current settings.
+func (b *Bulb) State() (*State, error) {
+	props, err := b.GetProp(stateProps...)
+	if err != nil {
+		return nil, err
+	}
+	return parseState(props)
+}
+
+// Snapshot will query the light bulbs current settings so they can be
+// restored later with Restore, e.g. after a temporary effect.
+func (b *Bulb) Snapshot() (*State, error) {
+	return b.State()
+}
+
+// Restore will set the light bulb to a state returned by Snapshot. A light
+// bulb which was on is set to the color of the states color mode and its
+// brightness in a single scene, one which was off is turned off. A nil state
+// returns an error without changing the light bulb.
+func (b *Bulb) Restore(s *State) error {
+	if s == nil {
+		return errors.New("cannot restore a nil state")
+	}
+	if !s.Power {
+		return b.TurnOff()
+	}
+
+	var scene Scene
+	switch s.ColorMode {
+	case ColorModeRGB:
+		rgb := int(s.RGB.R)<<16 | int(s.RGB.G)<<8 | int(s.RGB.B)
+		scene = ColorScene(rgb, s.Brightness)
+	case ColorModeHSV:
+		scene = HSVScene(s.Hue, s.Saturation, s.Brightness)
+	default:
+		scene = CTScene(s.ColorTemp, s.Brightness)
+	}
+	return b.SetScene(scene)
+}
+
+// StatesOf will query the current settings of all light bulbs concurrently.
+// Each query is bounded by the timeout of its bulb. The states of the light
+// bulbs which answered are returned along with the joined errors of the
+// others, each prefixed with the address of the failed light bulb.
+func StatesOf(bulbs []*Bulb) (map[*Bulb]*State, error) {
+	states := make([]*State, len(bulbs))
+	errs := make([]error, len(bulbs))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < stateWorkers && w < len(bulbs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				state, err := bulbs[i].State()
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %w", bulbs[i].address, err)
+					continue
+				}
+				states[i] = state
+			}
+		}()
+	}
+	for i := range bulbs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	result := make(map[*Bulb]*State, len(bulbs))
+	for i, state := range states {
+		if state != nil {
+			result[bulbs[i]] = state
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// ColorMode will query which setting currently determines the light bulbs
+// color.
+func (b *Bulb) ColorMode() (ColorMode, error) {
+	props, err := b.GetProp("color_mode")
+	if err != nil {
+		return 0, err
+	}
+	mode, err := strconv.Atoi(props["color_mode"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid color_mode property: %+v", err)
+	}
+	return ColorMode(mode), nil
+}
+
+// CurrentRGB will query the light bulbs current red, green and blue values,
+// the inverse of RGB. The values are kept by the light bulb while it is in
+// another color mode.
+func (b *Bulb) CurrentRGB() (red, green, blue int, err error) {
+	props, err := b.GetProp("rgb")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	rgb, err := strconv.Atoi(props["rgb"])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid rgb property: %+v", err)
+	}
+	c := rgbColor(rgb)
+	return int(c.R), int(c.G), int(c.B), nil
+}
+
+// parseState converts the queried properties into a State.
+func parseState(props map[string]string) (*State, error) {
+	var rgb, mode int
+	state := &State{
+		Power: props["power"] == "on",
+	}
+
+	fields := []struct {
+		prop  string
+		value *int
+	}{
+		{"bright", &state.Brightness},
+		{"ct", &state.ColorTemp},
+		{"rgb", &rgb},
+		{"hue", &state.Hue},
+		{"sat", &state.Saturation},
+		{"color_mode", &mode},
+	}
+	for _, field := range fields {
+		value, err := parseIntProp(props[field.prop])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s property: %+v", field.prop, err)
+		}
+		*field.value = value
+	}
+
+	state.RGB = rgbColor(rgb)
+	state.ColorMode = ColorMode(mode)
+	return state, nil
+}
+
+// parseIntProp parses an integer property. Empty values, returned for
+// unsupported properties, are treated as zero.
+func parseIntProp(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// rgbColor splits a combined rgb value into its channels.
+func rgbColor(rgb int) color.RGBA {
+	return color.RGBA{
+		R: uint8(rgb >> 16),
+		G: uint8(rgb >> 8),
+		B: uint8(rgb),
+		A: 0xff,
+	}
+}
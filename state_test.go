@@ -0,0 +1,75 @@
+package yeelight
+
+import "testing"
+
+func TestCurrentRGB(t *testing.T) {
+	tests := []struct {
+		rgb     string
+		r, g, b int
+		wantErr bool
+	}{
+		{rgb: "0", r: 0, g: 0, b: 0},
+		{rgb: "16777215", r: 255, g: 255, b: 255},
+		{rgb: "16746496", r: 255, g: 136, b: 0},
+		{rgb: "255", r: 0, g: 0, b: 255},
+		{rgb: "", wantErr: true},
+		{rgb: "red", wantErr: true},
+	}
+	for _, tt := range tests {
+		b, srv := mockBulb(t)
+		handleProps(srv, map[string]string{"rgb": tt.rgb})
+
+		r, g, bl, err := b.CurrentRGB()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("rgb %q: expected an error", tt.rgb)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("rgb %q: %v", tt.rgb, err)
+			continue
+		}
+		if r != tt.r || g != tt.g || bl != tt.b {
+			t.Errorf("rgb %q = %d, %d, %d, want %d, %d, %d", tt.rgb, r, g, bl, tt.r, tt.g, tt.b)
+		}
+	}
+}
+
+func TestRestoreNil(t *testing.T) {
+	b, srv := mockBulb(t)
+	if err := b.Restore(nil); err == nil {
+		t.Error("expected an error for a nil state")
+	}
+	if n := len(srv.Commands()); n != 0 {
+		t.Errorf("Restore(nil) sent %d commands", n)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	b, srv := mockBulb(t)
+	handleProps(srv, map[string]string{
+		"power": "on", "bright": "40", "ct": "3000", "rgb": "16746496",
+		"hue": "30", "sat": "100", "color_mode": "1",
+	})
+	s, err := b.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	got := lastCommand(t, srv, "set_scene").Params
+	want := []interface{}{"color", float64(16746496), float64(40)}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Restore sent %v, want %v", got, want)
+	}
+
+	s.Power = false
+	if err := b.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommand(t, srv, "set_power").Params[0]; got != "off" {
+		t.Errorf("Restore of an off state sent %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package yeelight
+
+import "fmt"
+
+// ValidationError is returned when a value is outside of the range supported
+// by the light bulb.
+type ValidationError struct {
+	Field string
+	Value int
+	Min   int
+	Max   int
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %d: must be between %d and %d", e.Field, e.Value, e.Min, e.Max)
+}
+
+// validate returns a ValidationError if v is outside of the range from low
+// to high.
+func validate(field string, v, low, high int) error {
+	if v < low || v > high {
+		return &ValidationError{Field: field, Value: v, Min: low, Max: high}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package yeelight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetBrightnessStrict(t *testing.T) {
+	for _, brightness := range []int{-1, 0, 101} {
+		b, srv := mockBulb(t)
+		err := b.SetBrightnessStrict(brightness)
+		var valErr *ValidationError
+		if !errors.As(err, &valErr) {
+			t.Errorf("SetBrightnessStrict(%d) = %v, want a ValidationError", brightness, err)
+			continue
+		}
+		want := ValidationError{Field: "brightness", Value: brightness, Min: 1, Max: 100}
+		if *valErr != want {
+			t.Errorf("SetBrightnessStrict(%d) = %+v, want %+v", brightness, *valErr, want)
+		}
+		if n := len(srv.Commands()); n != 0 {
+			t.Errorf("SetBrightnessStrict(%d) sent %d commands", brightness, n)
+		}
+	}
+
+	b, srv := mockBulb(t)
+	for _, brightness := range []int{1, 100} {
+		if err := b.SetBrightnessStrict(brightness); err != nil {
+			t.Errorf("SetBrightnessStrict(%d): %v", brightness, err)
+		}
+		if got := lastCommand(t, srv, "set_bright").Params[0]; got != float64(brightness) {
+			t.Errorf("SetBrightnessStrict(%d) sent %v", brightness, got)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		v, want int
+		wantErr bool
+	}{
+		{v: 0, want: 1, wantErr: true},
+		{v: 1, want: 1},
+		{v: 50, want: 50},
+		{v: 100, want: 100},
+		{v: 101, want: 100, wantErr: true},
+	}
+	for _, strict := range []bool{false, true} {
+		b := &Bulb{strict: strict}
+		for _, tt := range tests {
+			v := tt.v
+			err := b.fit("brightness", &v, 1, 100)
+			if strict {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("strict fit(%d): got error %v", tt.v, err)
+				}
+				if v != tt.v {
+					t.Errorf("strict fit(%d) changed the value to %d", tt.v, v)
+				}
+				continue
+			}
+			if err != nil || v != tt.want {
+				t.Errorf("fit(%d) = %d, %v, want %d", tt.v, v, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestStrictValidationSetters(t *testing.T) {
+	tests := []struct {
+		name   string
+		send   func(b *Bulb) error
+		field  string
+		method string
+		want   float64
+	}{
+		{"RGB", func(b *Bulb) error { return b.RGB(0, 300, 0) }, "green", "set_rgb", 0x00FF00},
+		{"Brightness", func(b *Bulb) error { return b.Brightness(150) }, "brightness", "set_bright", 100},
+		{"ColorTemp", func(b *Bulb) error { return b.ColorTemp(1000) }, "color temperature", "set_ct_abx", 1700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, srv := mockBulb(t)
+			if err := tt.send(b); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastCommand(t, srv, tt.method).Params[0]; got != tt.want {
+				t.Errorf("clamped to %v, want %v", got, tt.want)
+			}
+
+			b, srv = mockBulb(t, WithStrictValidation())
+			err := tt.send(b)
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) || valErr.Field != tt.field {
+				t.Errorf("got %v, want a ValidationError for %s", err, tt.field)
+			}
+			if n := len(srv.Commands()); n != 0 {
+				t.Errorf("strict setter sent %d commands", n)
+			}
+		})
+	}
+}
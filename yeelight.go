@@ -1,24 +1,148 @@
 package yeelight
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// command is send to the light bulb.
+// command is send to the light bulb. Every command is a single line of JSON
+// terminated by \r\n, e.g.
+//
+//	{"id":1,"method":"set_power","params":["on","smooth",500]}
+//
+// Commands without arguments are sent with empty params, never null ones.
 type command struct {
 	ID     int           `json:"id"`
 	Method string        `json:"method"`
 	Params []interface{} `json:"params"`
 }
 
-// response is returned/received by the light bulb.
+// response is returned/received by the light bulb. It holds either the result
+// or the error of the command with the same id, e.g.
+//
+//	{"id":1,"result":["ok"]}
+//	{"id":2,"error":{"code":-1,"message":"unsupported method"}}
 type response struct {
-	ID     int      `json:"id"`
-	Result []string `json:"result"`
+	ID     int               `json:"id"`
+	Result []json.RawMessage `json:"result"`
+	Error  *BulbError        `json:"error"`
+}
+
+// results converts the result values to strings.
+func (r *response) results() []string {
+	results := make([]string, len(r.Result))
+	for i, raw := range r.Result {
+		results[i] = rawString(raw)
+	}
+	return results
+}
+
+// rawString converts a JSON value to a string. String values are unquoted,
+// other values like numbers or objects are kept as JSON.
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// BulbError is returned when the light bulb rejects a command. Unlike
+// transport errors, retrying the command usually fails again.
+type BulbError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *BulbError) Error() string {
+	return fmt.Sprintf("bulb error %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether the error matches target. A rejection of an unknown
+// method matches ErrUnsupported, whether the firmware words it "unsupported
+// method", as in the specification, or "method not supported".
+func (e *BulbError) Is(target error) bool {
+	if target != ErrUnsupported {
+		return false
+	}
+	msg := strings.ToLower(e.Message)
+	return strings.Contains(msg, "unsupported") || strings.Contains(msg, "not supported")
+}
+
+// CommandError is returned when a command fails. It records the id and method
+// of the command along with the reason, e.g. a BulbError or a transport
+// error. The id is 0 if the command failed before it was assigned one.
+type CommandError struct {
+	ID     int
+	Method Method
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("command %d (%s): %v", e.ID, e.Method, e.Err)
+}
+
+// Unwrap returns the reason the command failed.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// commandError wraps err, if any, in a CommandError.
+func commandError(id int, method Method, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CommandError{ID: id, Method: method, Err: err}
+}
+
+// DefaultPort is the port light bulbs listen on for commands.
+const DefaultPort = 55443
+
+// ErrClosed is returned when sending commands to a closed light bulb.
+var ErrClosed = errors.New("bulb is closed")
+
+// ErrUnsupported is returned when the light bulb does not support a method.
+var ErrUnsupported = errors.New("method not supported")
+
+// ErrTransport is matched by errors of failed connections, e.g. if dialing,
+// writing or reading failed, so that they can be told apart from rejections by
+// the light bulb, see BulbError.
+var ErrTransport = errors.New("transport error")
+
+// transportErr marks an error as a failure of the connection.
+type transportErr struct {
+	err error
+}
+
+// transportError wraps err so that it matches ErrTransport.
+func transportError(err error) error {
+	return &transportErr{err: err}
+}
+
+// Error implements the error interface.
+func (e *transportErr) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *transportErr) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrTransport.
+func (e *transportErr) Is(target error) bool {
+	return target == ErrTransport
 }
 
 // Method describes the method to send to the light bulb.
@@ -31,6 +155,26 @@ var (
 	MethodSetBrightness Method = "set_bright"
 	MethodSetPower      Method = "set_power"
 	MethodToggle        Method = "toggle"
+	MethodGetProp       Method = "get_prop"
+	MethodStartCF       Method = "start_cf"
+	MethodStopCF        Method = "stop_cf"
+	MethodSetScene      Method = "set_scene"
+	MethodCronAdd       Method = "cron_add"
+	MethodCronGet       Method = "cron_get"
+	MethodCronDel       Method = "cron_del"
+	MethodSetAdjust     Method = "set_adjust"
+	MethodAdjustBright  Method = "adjust_bright"
+	MethodAdjustCT      Method = "adjust_ct"
+	MethodAdjustColor   Method = "adjust_color"
+	MethodSetName       Method = "set_name"
+	MethodSetDefault    Method = "set_default"
+	MethodBgSetPower    Method = "bg_set_power"
+	MethodBgSetRGB      Method = "bg_set_rgb"
+	MethodBgSetBright   Method = "bg_set_bright"
+	MethodBgSetCTABX    Method = "bg_set_ct_abx"
+	MethodBgToggle      Method = "bg_toggle"
+	MethodDevToggle     Method = "dev_toggle"
+	MethodSetMusic      Method = "set_music"
 )
 
 // Convert a Method to string
@@ -43,91 +187,574 @@ func (m *Method) String() string {
 
 // Bulb struct is used to control the lights.
 type Bulb struct {
-	mu    sync.Mutex
-	cmdID int
-	conn  net.Conn
+	mu         sync.Mutex
+	cmdID      int
+	address    string
+	conn       *connection
+	timeout    time.Duration
+	dial       DialFunc
+	support    map[Method]bool
+	maxBackoff time.Duration
+	keepAlive  time.Duration
+	heartbeat  time.Duration
+	onDelay    time.Duration
+	status     atomic.Int32
+	ctMin      int
+	ctMax      int
+	bgCtMin    int
+	bgCtMax    int
+	brightMin  int
+	brightMax  int
+	music      net.Conn
+	musicLn    net.Listener
+	limiter    *rateLimiter
+	logger     func(LogEvent)
+	strict     bool
+	lenient    bool
+	reproduce  bool
+	zeroOff    bool
+	effect     []interface{}
+
+	closed        chan struct{}
+	notifications chan StateChange
+	handlersMu    sync.Mutex
+	handlers      []func(StateChange)
+	changes       []StateChange
+	dispatching   bool
+
+	errMu   sync.Mutex
+	lastErr error
+
+	// reconnectMu serializes Reconnect calls, reopening is set while a
+	// closed bulb is reconnected.
+	reconnectMu sync.Mutex
+	reopening   bool
 }
 
-// NewBulb creates a new Bulb object.
-func NewBulb(address string) (*Bulb, error) {
-	if !strings.Contains(address, ":") {
-		address = address + ":55443"
-	}
-	conn, err := net.Dial("tcp", address)
+// NewBulb creates a new Bulb object. Options can be passed to change the
+// defaults, e.g. the time a command may take.
+func NewBulb(address string, opts ...Option) (*Bulb, error) {
+	b := newBulb(withDefaultPort(address), opts)
+	err := b.connect(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("could not dial address: %+v", err)
+		return nil, err
 	}
-	return &Bulb{
-		conn: conn,
-	}, nil
+	b.startHeartbeat()
+	return b, nil
+}
+
+// NewBulbConn creates a new Bulb object using an established connection, e.g.
+// one end of a net.Pipe in tests. Reconnects dial the connection's remote
+// address.
+func NewBulbConn(conn net.Conn, opts ...Option) *Bulb {
+	b := newBulb(conn.RemoteAddr().String(), opts)
+	b.attach(conn)
+	b.startHeartbeat()
+	return b
+}
+
+func newBulb(address string, opts []Option) *Bulb {
+	b := &Bulb{
+		address:       address,
+		timeout:       DefaultTimeout,
+		dial:          (&net.Dialer{}).DialContext,
+		ctMin:         defaultColorTempMin,
+		ctMax:         defaultColorTempMax,
+		bgCtMin:       defaultColorTempMin,
+		bgCtMax:       defaultColorTempMax,
+		closed:        make(chan struct{}),
+		notifications: make(chan StateChange, notificationBuffer),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// withDefaultPort appends the default port to addresses without a port. IPv6
+// addresses may be passed with or without brackets.
+func withDefaultPort(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(DefaultPort))
 }
 
 // Send can be used to send commands to the light bulb. Each command is defined
 // by a method and possible list of arguments. If the command can not be executed
-// successfully the Send method will return an error, otherwise nil.
-func (b *Bulb) Send(method Method, args ...interface{}) error {
+// successfully the Send method will return a CommandError, otherwise the
+// result returned by the light bulb.
+func (b *Bulb) Send(method Method, args ...interface{}) ([]string, error) {
+	return b.SendContext(context.Background(), method, args...)
+}
+
+// SendContext works like Send but aborts the command when the context is
+// canceled or its deadline is exceeded. In that case the error wraps the
+// context's error. The timeout configured on the bulb applies as well.
+// SendContext is safe for concurrent use, responses are matched to commands
+// by their id. Methods the light bulb doesn't support fail with
+// ErrUnsupported without being sent, see Supports.
+func (b *Bulb) SendContext(ctx context.Context, method Method, args ...interface{}) ([]string, error) {
+	if !b.Supports(method) {
+		return nil, commandError(0, method, ErrUnsupported)
+	}
+	return b.sendContext(ctx, method, args)
+}
+
+// SendRaw sends a command with any method, e.g. one added by a newer firmware
+// without a typed wrapper yet, and returns the result returned by the light
+// bulb. Unlike Send it bypasses the client side validation and the check
+// whether the light bulb supports the method.
+func (b *Bulb) SendRaw(method string, params ...interface{}) ([]string, error) {
+	return b.sendContext(context.Background(), Method(method), params)
+}
+
+// sendContext executes a single command and records its error for LastError.
+func (b *Bulb) sendContext(ctx context.Context, method Method, args []interface{}) ([]string, error) {
+	result, err := b.execute(ctx, method, args)
+	b.errMu.Lock()
+	b.lastErr = err
+	b.errMu.Unlock()
+	return result, err
+}
+
+// execute executes a single command, reconnecting once if enabled.
+func (b *Bulb) execute(ctx context.Context, method Method, args []interface{}) ([]string, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	if b.limiter != nil && !b.inMusicMode() {
+		err := b.limiter.wait(ctx)
+		if err != nil {
+			return nil, commandError(0, method, err)
+		}
+	}
+
+	result, failed, err := b.send(ctx, method, args)
+	if failed == nil || b.maxBackoff <= 0 || ctx.Err() != nil {
+		return result, err
+	}
+
+	err = b.reconnect(ctx, failed)
+	if err != nil {
+		return nil, commandError(0, method, err)
+	}
+	result, _, err = b.send(ctx, method, args)
+	return result, err
+}
+
+// LastError returns the error of the last command sent with Send, SendContext
+// or SendRaw, or nil if it succeeded. Methods rejected by Supports are not
+// recorded.
+func (b *Bulb) LastError() error {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+	return b.lastErr
+}
+
+// send executes a single command. If the connection failed, it is returned
+// along with the error.
+func (b *Bulb) send(ctx context.Context, method Method, args []interface{}) ([]string, *connection, error) {
+	result, id, failed, err := b.await(ctx, method, args)
+	return result, failed, commandError(id, method, err)
+}
+
+// await writes a single command and waits for its response. It returns the
+// id of the command along with the result.
+func (b *Bulb) await(ctx context.Context, method Method, args []interface{}) ([]string, int, *connection, error) {
+	conn, id, reply, err := b.write(ctx, method, args)
+	if err != nil {
+		return nil, id, conn, err
+	}
+	if reply == nil {
+		// Commands sent in music mode don't receive a response.
+		return nil, id, nil, nil
+	}
+	defer conn.removePending(id)
+
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return nil, id, nil, resp.Error
+		}
+		return resp.results(), id, nil, nil
+	case <-conn.done:
+		if conn.err == ErrClosed {
+			return nil, id, nil, ErrClosed
+		}
+		return nil, id, conn, transportError(fmt.Errorf("receiving response: %+v", conn.err))
+	case <-ctx.Done():
+		return nil, id, nil, ctx.Err()
+	}
+}
+
+// write sends a command to the light bulb. It returns the connection and id
+// of the command along with the channel its response is delivered on, which
+// is nil in music mode. If writing fails, the connection and id are returned
+// along with the error. The command is queued for the writer of the
+// connection, so a slow write doesn't block other callers while they prepare
+// their commands. Commands of a single caller are written in order.
+func (b *Bulb) write(ctx context.Context, method Method, args []interface{}) (*connection, int, <-chan response, error) {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+	if b.conn == nil {
+		b.mu.Unlock()
+		return nil, 0, nil, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		b.mu.Unlock()
+		return nil, 0, nil, err
+	}
+
+	conn := b.conn
+	select {
+	case <-conn.done:
+		b.mu.Unlock()
+		return conn, 0, nil, conn.failure()
+	default:
+	}
 
+	if args == nil {
+		args = []interface{}{}
+	}
 	cmd := command{
 		ID:     b.cmdID,
 		Method: method.String(),
 		Params: args,
 	}
+	b.cmdID++
 
-	err := json.NewEncoder(b.conn).Encode(cmd)
-	if err != nil {
-		return fmt.Errorf("cannot write json: %+v", err)
+	if b.music != nil && method != MethodSetMusic {
+		defer b.mu.Unlock()
+		return nil, cmd.ID, nil, b.writeCommand(ctx, b.music, cmd)
 	}
 
-	_, err = fmt.Fprint(b.conn, "\r\n")
+	// The response may arrive before the write returns, so the command has
+	// to be registered first.
+	reply := conn.addPending(cmd.ID)
+	b.mu.Unlock()
+
+	err := conn.enqueue(ctx, cmd)
 	if err != nil {
-		return fmt.Errorf("cannot write trailer: %+v", err)
+		conn.removePending(cmd.ID)
+		if ctx.Err() != nil {
+			return nil, cmd.ID, nil, ctx.Err()
+		}
+		if err == ErrClosed {
+			// Close already set the status.
+			return nil, cmd.ID, nil, err
+		}
+		b.status.Store(int32(StatusDisconnected))
+		return conn, cmd.ID, nil, err
 	}
+	return conn, cmd.ID, reply, nil
+}
 
-	var resp response
-	err = json.NewDecoder(b.conn).Decode(&resp)
-	if err != nil {
-		return fmt.Errorf("receiving response: %+v", err)
+// contextError returns the context's error if it is done, otherwise err.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
+	return err
+}
 
-	b.cmdID++
+// Close closes the connection to the light bulb. After Close the bulb can no
+// longer be used until Reconnect is called and Send will return an error
+// wrapping ErrClosed, just like commands still waiting for their response.
+// Closing an already closed bulb is a no-op.
+func (b *Bulb) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		if b.reopening {
+			// Abort the pending Reconnect, which closes Notifications.
+			b.reopening = false
+			close(b.closed)
+			b.status.Store(int32(StatusClosed))
+		}
+		return nil
+	}
+	close(b.closed)
+	b.closeMusic()
+	err := b.conn.close()
+	close(b.notifications)
+	b.conn = nil
+	b.status.Store(int32(StatusClosed))
+	if err != nil {
+		return fmt.Errorf("could not close connection: %+v", err)
+	}
 	return nil
 }
 
+// String implements the fmt.Stringer interface. It prints the address and
+// connection status of the light bulb, e.g. "192.168.1.2:55443 (connected)".
+// It doesn't lock the bulb, so it is safe to use from loggers and handlers.
+func (b *Bulb) String() string {
+	return fmt.Sprintf("%s (%s)", b.address, b.Status())
+}
+
+// CommandID returns the id of the last command sent on the current
+// connection, or 0 if none was sent yet.
+func (b *Bulb) CommandID() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cmdID - 1
+}
+
+// Addr returns the address of the light bulb. While connected, it is the
+// remote address of the connection, otherwise the address passed to NewBulb.
+func (b *Bulb) Addr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return b.address
+	}
+	return b.conn.RemoteAddr().String()
+}
+
+// LocalAddr returns the local address of the connection to the light bulb,
+// which is the address the light bulb can reach this host on, e.g. for music
+// mode. It returns nil once the bulb is closed.
+func (b *Bulb) LocalAddr() net.Addr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return nil
+	}
+	return b.conn.LocalAddr()
+}
+
 // TurnOn will turn the light bulb on.
 func (b *Bulb) TurnOn() error {
-	return b.Send(MethodSetPower, "on")
+	return b.setPower("on")
 }
 
 // TurnOff will turn the light bulb off.
 func (b *Bulb) TurnOff() error {
-	return b.Send(MethodSetPower, "off")
+	return b.setPower("off")
+}
+
+// Toggle will turn the light bulb on if it is off and vice versa.
+func (b *Bulb) Toggle() error {
+	_, err := b.Send(MethodToggle)
+	return err
+}
+
+// ToggleAndState will toggle the light bulb and return whether it is on
+// afterwards.
+func (b *Bulb) ToggleAndState() (bool, error) {
+	err := b.Toggle()
+	if err != nil {
+		return false, err
+	}
+	return b.IsOn()
+}
+
+func (b *Bulb) setPower(power string, effect ...interface{}) error {
+	_, err := b.Send(MethodSetPower, append([]interface{}{power}, effect...)...)
+	if err != nil || power != "on" {
+		return err
+	}
+	return b.settle()
+}
+
+// ensureOn turns the light bulb on if it is off. In music mode the power
+// can't be queried, the light bulb is turned on unconditionally then.
+func (b *Bulb) ensureOn(effect ...interface{}) error {
+	if !b.inMusicMode() {
+		on, err := b.IsOn()
+		if err != nil || on {
+			return err
+		}
+	}
+	return b.setPower("on", effect...)
+}
+
+// settle waits until the light bulb reports being on, for at most the delay
+// set by WithPowerOnSettleDelay. Music mode doesn't report the power, the
+// full delay is waited then.
+func (b *Bulb) settle() error {
+	if b.onDelay <= 0 {
+		return nil
+	}
+	if b.inMusicMode() {
+		time.Sleep(b.onDelay)
+		return nil
+	}
+
+	deadline := time.Now().Add(b.onDelay)
+	for {
+		on, err := b.IsOn()
+		if err != nil {
+			return err
+		}
+		wait := time.Until(deadline)
+		if on || wait <= 0 {
+			return nil
+		}
+		if wait > settlePoll {
+			wait = settlePoll
+		}
+		time.Sleep(wait)
+	}
 }
 
-// ColorTemp will set the light bulbs color temperature
+// ColorTemp will set the light bulbs color temperature. Values outside of the
+// range supported by the light bulb are clamped.
 func (b *Bulb) ColorTemp(temp int) error {
-	switch {
-	case temp < 1700:
-		temp = 1700
-	case temp > 6500:
-		temp = 6500
+	return b.colorTemp(temp, b.effect...)
+}
+
+func (b *Bulb) colorTemp(temp int, effect ...interface{}) error {
+	err := b.fit("color temperature", &temp, b.ctMin, b.ctMax)
+	if err != nil {
+		return err
 	}
-	return b.Send(MethodSetCTABX, temp)
+	_, err = b.Send(MethodSetCTABX, append([]interface{}{temp}, effect...)...)
+	return err
+}
+
+// WhiteBalance will set the light bulbs color temperature as a percentage of
+// its supported range. 0 is the coldest and 100 the warmest white.
+func (b *Bulb) WhiteBalance(percent int) error {
+	percent = clamp(percent, 0, 100)
+	return b.ColorTemp(b.ctMax - percent*(b.ctMax-b.ctMin)/100)
 }
 
 // RGB will set the light bulbs red, green and blue values.
 func (b *Bulb) RGB(red, green, blue int) error {
-	return b.Send(MethodSetRGB, red<<16+green<<8+blue)
+	return b.rgb(red, green, blue, b.effect...)
+}
+
+func (b *Bulb) rgb(red, green, blue int, effect ...interface{}) error {
+	err := errors.Join(
+		b.fit("red", &red, 0, 255),
+		b.fit("green", &green, 0, 255),
+		b.fit("blue", &blue, 0, 255),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = b.Send(MethodSetRGB, append([]interface{}{red<<16 + green<<8 + blue}, effect...)...)
+	return err
 }
 
-// Brightness will set the light bulbs brightness.
+// HSV will set the light bulbs hue and saturation. The brightness is not
+// affected, use Brightness to change it.
+func (b *Bulb) HSV(hue, sat int) error {
+	return b.hsv(hue, sat, b.effect...)
+}
+
+func (b *Bulb) hsv(hue, sat int, effect ...interface{}) error {
+	err := errors.Join(
+		b.fit("hue", &hue, 0, 359),
+		b.fit("saturation", &sat, 0, 100),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = b.Send(MethodSetHSV, append([]interface{}{hue, sat}, effect...)...)
+	return err
+}
+
+// Brightness will set the light bulbs brightness. The light bulb supports a
+// range of 1 to 100, values outside are clamped unless strict validation is
+// enabled. Note that 0 does not turn the light bulb off but sets the lowest
+// brightness, unless WithZeroBrightnessOff is used.
 func (b *Bulb) Brightness(brightness int) error {
+	return b.brightness(brightness, b.effect...)
+}
+
+// SetBrightnessStrict works like Brightness but returns a ValidationError for
+// values outside of the supported range instead of clamping them.
+func (b *Bulb) SetBrightnessStrict(brightness int) error {
+	err := validate("brightness", brightness, 1, 100)
+	if err != nil {
+		return err
+	}
+	return b.brightness(brightness, b.effect...)
+}
+
+func (b *Bulb) brightness(brightness int, effect ...interface{}) error {
+	if b.zeroOff && brightness <= 0 {
+		return b.setPower("off", effect...)
+	}
+	err := b.fit("brightness", &brightness, 1, 100)
+	if err != nil {
+		return err
+	}
+	if b.zeroOff {
+		err = b.ensureOn(effect...)
+		if err != nil {
+			return err
+		}
+	}
+	brightness = b.remapBrightness(brightness)
+	_, err = b.Send(MethodSetBrightness, append([]interface{}{brightness}, effect...)...)
+	return err
+}
+
+// remapBrightness maps a brightness from 1 to 100 into the bounds set by
+// WithBrightnessBounds, if any.
+func (b *Bulb) remapBrightness(brightness int) int {
+	if b.brightMax == 0 {
+		return brightness
+	}
+	span := float64(b.brightMax - b.brightMin)
+	return b.brightMin + int(math.Round(float64(brightness-1)*span/99))
+}
+
+// unmapBrightness is the inverse of remapBrightness. It maps a brightness read
+// from the light bulb back into the range from 1 to 100.
+func (b *Bulb) unmapBrightness(brightness int) int {
+	if b.brightMax == 0 {
+		return brightness
+	}
+	if b.brightMax == b.brightMin {
+		return 100
+	}
+	brightness = clamp(brightness, b.brightMin, b.brightMax)
+	span := float64(b.brightMax - b.brightMin)
+	return 1 + int(math.Round(float64(brightness-b.brightMin)*99/span))
+}
+
+// SetDefault will save the current state of the light bulb. The light bulb
+// restores this state after it was powered on.
+func (b *Bulb) SetDefault() error {
+	_, err := b.Send(MethodSetDefault)
+	return err
+}
+
+// Supports reports whether the light bulb supports the given method. If the
+// supported methods are unknown, e.g. because the bulb wasn't discovered,
+// all methods are reported as supported.
+func (b *Bulb) Supports(method Method) bool {
+	return b.support == nil || b.support[method]
+}
+
+// fit clamps v to the range from low to high. With strict validation enabled
+// it returns a ValidationError for values outside of the range instead.
+func (b *Bulb) fit(field string, v *int, low, high int) error {
+	if b.strict {
+		return validate(field, *v, low, high)
+	}
+	*v = clamp(*v, low, high)
+	return nil
+}
+
+// clamp limits v to the range from low to high.
+func clamp(v, low, high int) int {
 	switch {
-	case brightness > 100:
-		brightness = 100
-	case brightness < 1:
-		brightness = 1
+	case v < low:
+		return low
+	case v > high:
+		return high
 	}
-	return b.Send(MethodSetBrightness, brightness)
+	return v
 }
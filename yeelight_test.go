@@ -0,0 +1,447 @@
+package yeelight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juliusmh/go-yeelight/yeelighttest"
+)
+
+func TestCommandWireFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(b *Bulb) error
+		want string
+	}{
+		{"TurnOn", (*Bulb).TurnOn, `{"id":1,"method":"set_power","params":["on"]}`},
+		{"TurnOff", (*Bulb).TurnOff, `{"id":1,"method":"set_power","params":["off"]}`},
+		{"Toggle", (*Bulb).Toggle, `{"id":1,"method":"toggle","params":[]}`},
+		{"TurnOnWithEffect", func(b *Bulb) error {
+			return b.TurnOnWithEffect(Smooth, 500*time.Millisecond)
+		}, `{"id":1,"method":"set_power","params":["on","smooth",500]}`},
+		{"ColorTemp", func(b *Bulb) error {
+			return b.ColorTemp(4000)
+		}, `{"id":1,"method":"set_ct_abx","params":[4000]}`},
+		{"RGB", func(b *Bulb) error {
+			return b.RGB(255, 136, 0)
+		}, `{"id":1,"method":"set_rgb","params":[16746496]}`},
+		{"HSV", func(b *Bulb) error {
+			return b.HSV(120, 50)
+		}, `{"id":1,"method":"set_hsv","params":[120,50]}`},
+		{"Brightness", func(b *Bulb) error {
+			return b.Brightness(80)
+		}, `{"id":1,"method":"set_bright","params":[80]}`},
+		{"StopColorFlow", (*Bulb).StopColorFlow, `{"id":1,"method":"stop_cf","params":[]}`},
+		{"SetScene", func(b *Bulb) error {
+			return b.SetScene(CTScene(2700, 40))
+		}, `{"id":1,"method":"set_scene","params":["ct",2700,40]}`},
+		{"SetPowerOffTimer", func(b *Bulb) error {
+			return b.SetPowerOffTimer(15 * time.Minute)
+		}, `{"id":1,"method":"cron_add","params":[0,15]}`},
+		{"SetDefault", (*Bulb).SetDefault, `{"id":1,"method":"set_default","params":[]}`},
+		{"BackgroundToggle", (*Bulb).BackgroundToggle, `{"id":1,"method":"bg_toggle","params":[]}`},
+		{"SendRaw", func(b *Bulb) error {
+			_, err := b.SendRaw("set_new", "x", 1)
+			return err
+		}, `{"id":1,"method":"set_new","params":["x",1]}`},
+		{"GetProp", func(b *Bulb) error {
+			_, err := b.GetProp("power", "bright")
+			return err
+		}, `{"id":1,"method":"get_prop","params":["power","bright"]}`},
+		{"StartColorFlow", func(b *Bulb) error {
+			return b.StartColorFlow(0, FlowActionStay, []FlowTuple{CTStep(time.Second, 2700, 50)})
+		}, `{"id":1,"method":"start_cf","params":[0,1,"1000,2,2700,50"]}`},
+		{"GetPowerOffTimer", func(b *Bulb) error {
+			// The fake's "ok" is no timer, only the command matters.
+			b.GetPowerOffTimer()
+			return nil
+		}, `{"id":1,"method":"cron_get","params":[0]}`},
+		{"ClearPowerOffTimer", (*Bulb).ClearPowerOffTimer, `{"id":1,"method":"cron_del","params":[0]}`},
+		{"AdjustBrightness", func(b *Bulb) error {
+			return b.AdjustBrightness(AdjustIncrease)
+		}, `{"id":1,"method":"set_adjust","params":["increase","bright"]}`},
+		{"NudgeBrightness", func(b *Bulb) error {
+			return b.NudgeBrightness(-20, time.Second)
+		}, `{"id":1,"method":"adjust_bright","params":[-20,1000]}`},
+		{"NudgeColorTemp", func(b *Bulb) error {
+			return b.NudgeColorTemp(20, 500*time.Millisecond)
+		}, `{"id":1,"method":"adjust_ct","params":[20,500]}`},
+		{"NudgeColor", func(b *Bulb) error {
+			return b.NudgeColor(50, time.Second)
+		}, `{"id":1,"method":"adjust_color","params":[50,1000]}`},
+		{"SetName", func(b *Bulb) error {
+			return b.SetName("kitchen")
+		}, `{"id":1,"method":"set_name","params":["kitchen"]}`},
+		{"BackgroundPower", func(b *Bulb) error {
+			return b.BackgroundPower(true)
+		}, `{"id":1,"method":"bg_set_power","params":["on"]}`},
+		{"BackgroundRGB", func(b *Bulb) error {
+			return b.BackgroundRGB(0, 255, 0)
+		}, `{"id":1,"method":"bg_set_rgb","params":[65280]}`},
+		{"BackgroundBrightness", func(b *Bulb) error {
+			return b.BackgroundBrightness(30)
+		}, `{"id":1,"method":"bg_set_bright","params":[30]}`},
+		{"BackgroundColorTemp", func(b *Bulb) error {
+			return b.BackgroundColorTemp(3500)
+		}, `{"id":1,"method":"bg_set_ct_abx","params":[3500]}`},
+		{"DeviceToggle", (*Bulb).DeviceToggle, `{"id":1,"method":"dev_toggle","params":[]}`},
+		{"DisableMusicMode", (*Bulb).DisableMusicMode, `{"id":1,"method":"set_music","params":[0]}`},
+	}
+
+	// Every method has a case above.
+	methods := []Method{
+		MethodSetCTABX, MethodSetRGB, MethodSetHSV, MethodSetBrightness,
+		MethodSetPower, MethodToggle, MethodGetProp, MethodStartCF,
+		MethodStopCF, MethodSetScene, MethodCronAdd, MethodCronGet,
+		MethodCronDel, MethodSetAdjust, MethodAdjustBright, MethodAdjustCT,
+		MethodAdjustColor, MethodSetName, MethodSetDefault, MethodBgSetPower,
+		MethodBgSetRGB, MethodBgSetBright, MethodBgSetCTABX, MethodBgToggle,
+		MethodDevToggle, MethodSetMusic,
+	}
+	for _, method := range methods {
+		covered := false
+		for _, tt := range tests {
+			covered = covered || strings.Contains(tt.want, `"method":"`+method.String()+`"`)
+		}
+		if !covered {
+			t.Errorf("no wire format case for %s", method)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, srv := mockBulb(t)
+			err := tt.send(b)
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			got := string(srv.Commands()[0].Raw)
+			if want := tt.want + "\r\n"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr bool
+		want    message
+	}{
+		{line: `{"id":1,"result":["ok"]}`, want: message{response: response{ID: 1, Result: []json.RawMessage{json.RawMessage(`"ok"`)}}}},
+		{line: `{"id":2,"error":{"code":-1,"message":"unsupported method"}}`, want: message{response: response{ID: 2, Error: &BulbError{Code: -1, Message: "unsupported method"}}}},
+		{line: `{"method":"props","params":{"power":"on"}}`, want: message{Method: "props", Params: map[string]json.RawMessage{"power": json.RawMessage(`"on"`)}}},
+		{line: `{"id":1,"result":`, wantErr: true},
+		{line: `not json`, wantErr: true},
+		{line: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeMessage([]byte(tt.line))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.line, err)
+			continue
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(tt.want)
+		if got.ID != tt.want.ID || got.Method != tt.want.Method || string(gotJSON) != string(wantJSON) {
+			t.Errorf("%q: got %s, want %s", tt.line, gotJSON, wantJSON)
+		}
+	}
+}
+
+func FuzzDecodeMessage(f *testing.F) {
+	f.Add([]byte(`{"id":1,"result":["ok"]}`))
+	f.Add([]byte(`{"id":2,"error":{"code":-1,"message":"unsupported method"}}`))
+	f.Add([]byte(`{"method":"props","params":{"power":"on","bright":"80"}}`))
+	f.Add([]byte("{\"id\":1,\"result\":[\"ok\"]}\r\n{\"id\":2"))
+	f.Add([]byte("\r\n\r\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, err := decodeMessage(data)
+		if err == nil && !json.Valid(data) {
+			t.Errorf("decoded malformed message %q", data)
+		}
+
+		// The reader must survive any input as well.
+		client, server := net.Pipe()
+		b := NewBulbConn(client)
+		go func() {
+			server.Write(data)
+			server.Write([]byte("\r\n"))
+			server.Close()
+		}()
+		<-b.conn.done
+		b.Close()
+	})
+}
+
+func TestCommandErrors(t *testing.T) {
+	t.Run("rejected", func(t *testing.T) {
+		b, srv := mockBulb(t)
+		srv.Reject("toggle", -1, "unsupported method")
+		_, err := b.Send(MethodToggle)
+		assertCommandError(t, err, MethodToggle, ErrUnsupported)
+		var bulbErr *BulbError
+		if !errors.As(err, &bulbErr) {
+			t.Errorf("%v is no BulbError", err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		b, _ := mockBulb(t, WithSupportedMethods(MethodSetPower))
+		_, err := b.Send(MethodToggle)
+		assertCommandError(t, err, MethodToggle, ErrUnsupported)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		b, _ := mockBulb(t, WithRateLimit(1))
+		b.Toggle()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := b.SendContext(ctx, MethodToggle)
+		assertCommandError(t, err, MethodToggle, context.DeadlineExceeded)
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		srv := yeelighttest.NewServer()
+		b, err := NewBulb(srv.Addr(), WithTimeout(200*time.Millisecond), WithAutoReconnect(time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		srv.Close()
+
+		_, err = b.Send(MethodToggle)
+		assertCommandError(t, err, MethodToggle, ErrTransport)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		b, _ := mockBulb(t)
+		b.Close()
+		_, err := b.Send(MethodToggle)
+		assertCommandError(t, err, MethodToggle, ErrClosed)
+	})
+}
+
+func TestBulbErrorUnsupported(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"unsupported method", true},
+		{"method not supported", true},
+		{"Unsupported Method", true},
+		{"invalid params", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		err := &BulbError{Code: -1, Message: tt.message}
+		if got := errors.Is(err, ErrUnsupported); got != tt.want {
+			t.Errorf("errors.Is(%q, ErrUnsupported) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+	if errors.Is(&BulbError{Code: -1, Message: "unsupported method"}, ErrClosed) {
+		t.Error("BulbError matches ErrClosed")
+	}
+}
+
+func TestCloseReleasesPendingSend(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(0))
+	srv.Mute()
+	conn := b.conn
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Send(MethodToggle)
+		errs <- err
+	}()
+
+	// Wait for the command to be pending.
+	for b.CommandID() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send is still blocked after Close")
+	}
+	select {
+	case <-conn.done:
+	case <-time.After(time.Second):
+		t.Error("reader is still running after Close")
+	}
+}
+
+func TestCancelReleasesPendingSend(t *testing.T) {
+	b, srv := mockBulb(t, WithTimeout(0))
+	srv.Mute()
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.SendContext(ctx, MethodToggle)
+		errs <- err
+	}()
+
+	for b.CommandID() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SendContext is still blocked after cancel")
+	}
+}
+
+func TestWithDefaultPort(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"192.168.1.50", "192.168.1.50:55443"},
+		{"192.168.1.50:1234", "192.168.1.50:1234"},
+		{"[fe80::1]", "[fe80::1]:55443"},
+		{"[fe80::1]:1234", "[fe80::1]:1234"},
+		{"fe80::1", "[fe80::1]:55443"},
+		{"bulb.local", "bulb.local:55443"},
+	}
+	for _, tt := range tests {
+		if got := withDefaultPort(tt.address); got != tt.want {
+			t.Errorf("withDefaultPort(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestRGBClamping(t *testing.T) {
+	tests := []struct {
+		red, green, blue int
+		want             float64
+	}{
+		{300, -5, 1000, 0xFF00FF},
+		{-1, -1, -1, 0},
+		{256, 256, 256, 0xFFFFFF},
+		{255, 0, 0, 0xFF0000},
+		{1000, 128, -1000, 0xFF8000},
+	}
+	for _, tt := range tests {
+		b, srv := mockBulb(t)
+		err := b.RGB(tt.red, tt.green, tt.blue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := lastCommand(t, srv, "set_rgb").Params[0]
+		if got != tt.want {
+			t.Errorf("RGB(%d, %d, %d) sent %v, want %v", tt.red, tt.green, tt.blue, got, tt.want)
+		}
+	}
+}
+
+func TestRGBStrict(t *testing.T) {
+	b, _ := mockBulb(t, WithStrictValidation())
+	err := b.RGB(300, -5, 0)
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) {
+		t.Fatalf("got %v, want a ValidationError", err)
+	}
+}
+
+func TestZeroBrightnessOff(t *testing.T) {
+	tests := []struct {
+		name       string
+		power      string
+		brightness int
+		opts       []Option
+		want       []string
+		wantErr    bool
+	}{
+		{name: "zero", power: "on", brightness: 0, want: []string{"set_power"}},
+		{name: "off", power: "off", brightness: 50, want: []string{"get_prop", "set_power", "set_bright"}},
+		{name: "on", power: "on", brightness: 50, want: []string{"get_prop", "set_bright"}},
+		{name: "strict", power: "off", brightness: 150, opts: []Option{WithStrictValidation()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, srv := mockBulb(t, append([]Option{WithZeroBrightnessOff()}, tt.opts...)...)
+			handleProps(srv, map[string]string{"power": tt.power})
+
+			err := b.Brightness(tt.brightness)
+			if tt.wantErr {
+				var valErr *ValidationError
+				if !errors.As(err, &valErr) {
+					t.Errorf("got %v, want a ValidationError", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, cmd := range srv.Commands() {
+				got = append(got, cmd.Method)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("sent %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("sent %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCloseInterruptsWrite(t *testing.T) {
+	// Writes to a pipe block until the other end reads, which it never does.
+	client, server := net.Pipe()
+	defer server.Close()
+	b := NewBulbConn(client, WithTimeout(0))
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Send(MethodToggle)
+		errs <- err
+	}()
+	for b.CommandID() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send is still blocked after Close")
+	}
+	if s := b.Status(); s != StatusClosed {
+		t.Errorf("status is %s, want closed", s)
+	}
+
+	// Reconnect replaces the closed channel of the bulb while the writer of
+	// the old connection may still be running. Dialing the pipe fails.
+	if err := b.Reconnect(); err == nil {
+		b.Close()
+	}
+}
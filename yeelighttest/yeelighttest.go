@@ -0,0 +1,300 @@
+// Package yeelighttest provides a mock light bulb for testing code which
+// controls Yeelights without a physical device.
+package yeelighttest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// fragmentDelay is the time waited between the fragments of a reply, so the
+// client receives them in separate reads.
+const fragmentDelay = 10 * time.Millisecond
+
+// Command is a command received by the mock light bulb. Raw holds the line
+// as received, including its trailer.
+type Command struct {
+	ID     int           `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+	Raw    []byte        `json:"-"`
+}
+
+// Error is returned by handlers to reject a command with a specific code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("bulb error %d: %s", e.Code, e.Message)
+}
+
+// Handler answers a command. Its result is sent to the client, an error
+// rejects the command. Errors other than *Error are sent with code -1.
+type Handler func(params []interface{}) ([]interface{}, error)
+
+// Server is a mock light bulb speaking the Yeelight protocol. Commands are
+// answered with "ok" unless a handler is registered for their method.
+type Server struct {
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	conns    map[net.Conn]bool
+	commands []Command
+	closed   bool
+	fragment int
+	coalesce int
+	trailer  string
+	muted    bool
+}
+
+// NewServer starts a mock light bulb listening on a local port. It panics if
+// it can't listen, like the servers of net/http/httptest.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("yeelighttest: could not listen: %+v", err))
+	}
+
+	s := &Server{
+		ln:       ln,
+		handlers: make(map[string]Handler),
+		conns:    make(map[net.Conn]bool),
+		trailer:  "\r\n",
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// StartMockBulb starts a mock light bulb and returns its address along with a
+// function stopping it.
+func StartMockBulb() (string, func()) {
+	s := NewServer()
+	return s.Addr(), s.Close
+}
+
+// Addr returns the address the mock light bulb listens on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Handle registers the handler answering commands of the given method.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// Reject makes the mock light bulb reject all commands of the given method.
+func (s *Server) Reject(method string, code int, message string) {
+	s.Handle(method, func([]interface{}) ([]interface{}, error) {
+		return nil, &Error{Code: code, Message: message}
+	})
+}
+
+// FragmentReplies makes the mock light bulb write each reply in two writes,
+// split after n bytes, to test clients reassembling split messages. A value of
+// zero writes replies at once again.
+func (s *Server) FragmentReplies(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fragment = n
+}
+
+// CoalesceReplies makes the mock light bulb hold back its replies on a
+// connection until n of them are pending and write them in a single write.
+// A value of zero or one writes each reply on its own again.
+func (s *Server) CoalesceReplies(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coalesce = n
+}
+
+// OmitTrailers makes the mock light bulb write its replies and notifications
+// without the \r\n trailer, like some older firmware.
+func (s *Server) OmitTrailers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = ""
+}
+
+// Mute makes the mock light bulb record commands without answering them, like
+// a stalled light bulb.
+func (s *Server) Mute() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = true
+}
+
+// Unmute answers commands again after Mute.
+func (s *Server) Unmute() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = false
+}
+
+// Notify pushes a state change notification to all connected clients.
+func (s *Server) Notify(props map[string]interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"method": "props",
+		"params": props,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode json: %+v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_, err := conn.Write(append(data, s.trailer...))
+		if err != nil {
+			return fmt.Errorf("cannot write notification: %+v", err)
+		}
+	}
+	return nil
+}
+
+// Commands returns all commands received so far.
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// Close stops the mock light bulb and closes all connections.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			// Close already closed the registered connections.
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	var pending []byte
+	held := 0
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var cmd Command
+		if json.Unmarshal(bytes.TrimSpace(line), &cmd) != nil {
+			continue
+		}
+		cmd.Raw = line
+
+		s.mu.Lock()
+		muted := s.muted
+		if muted {
+			s.commands = append(s.commands, cmd)
+		}
+		s.mu.Unlock()
+		if muted {
+			continue
+		}
+
+		data, err := json.Marshal(s.answer(cmd))
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		pending = append(append(pending, data...), s.trailer...)
+		held++
+		if held < s.coalesce {
+			s.mu.Unlock()
+			continue
+		}
+		err = s.write(conn, pending)
+		pending, held = nil, 0
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// write writes data to the connection, fragmented if configured. The caller
+// must hold s.mu.
+func (s *Server) write(conn net.Conn, data []byte) error {
+	if s.fragment > 0 && s.fragment < len(data) {
+		_, err := conn.Write(data[:s.fragment])
+		if err != nil {
+			return err
+		}
+		time.Sleep(fragmentDelay)
+		data = data[s.fragment:]
+	}
+	_, err := conn.Write(data)
+	return err
+}
+
+// answer records the command and builds its response.
+func (s *Server) answer(cmd Command) map[string]interface{} {
+	s.mu.Lock()
+	s.commands = append(s.commands, cmd)
+	h, ok := s.handlers[cmd.Method]
+	s.mu.Unlock()
+
+	if !ok {
+		return map[string]interface{}{"id": cmd.ID, "result": []interface{}{"ok"}}
+	}
+
+	result, err := h(cmd.Params)
+	if err != nil {
+		var bulbErr *Error
+		if !errors.As(err, &bulbErr) {
+			bulbErr = &Error{Code: -1, Message: err.Error()}
+		}
+		return map[string]interface{}{"id": cmd.ID, "error": bulbErr}
+	}
+	return map[string]interface{}{"id": cmd.ID, "result": result}
+}
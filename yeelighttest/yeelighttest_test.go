@@ -0,0 +1,101 @@
+package yeelighttest
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloseWhileAccepting(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		s := NewServer()
+		var wg sync.WaitGroup
+		for j := 0; j < 5; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn, err := net.Dial("tcp", s.Addr())
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				// The connection must be closed by the server, whether it
+				// was accepted before or after Close.
+				conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+				_, err = conn.Read(make([]byte, 1))
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					t.Error("connection stayed open after Close")
+				}
+			}()
+		}
+		s.Close()
+		wg.Wait()
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	s := NewServer()
+	s.Close()
+	s.Close()
+}
+
+// dialCommand connects to the server and sends a toggle command.
+func dialCommand(t *testing.T, s *Server) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := conn.Write([]byte("{\"id\":1,\"method\":\"toggle\",\"params\":[]}\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestOmitTrailers(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.OmitTrailers()
+
+	conn := dialCommand(t, s)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":1,"result":["ok"]}`; string(buf[:n]) != want {
+		t.Errorf("got %q, want %q", buf[:n], want)
+	}
+}
+
+func TestMute(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Mute()
+
+	conn := dialCommand(t, s)
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 64)); err == nil {
+		t.Error("muted server answered")
+	}
+	cmds := s.Commands()
+	if len(cmds) != 1 || cmds[0].Method != "toggle" {
+		t.Fatalf("got commands %+v", cmds)
+	}
+	if !bytes.HasSuffix(cmds[0].Raw, []byte("\r\n")) {
+		t.Errorf("raw command %q lacks its trailer", cmds[0].Raw)
+	}
+
+	s.Unmute()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	conn.Write([]byte("{\"id\":2,\"method\":\"toggle\",\"params\":[]}\r\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "{\"id\":2,\"result\":[\"ok\"]}\r\n" {
+		t.Errorf("got %q, %v after Unmute", line, err)
+	}
+}